@@ -0,0 +1,176 @@
+package main
+
+/*
+PC3 — binario único del pipeline
+
+Uso:
+  pc3 [--data=data] [--artifacts=artifacts] <comando> [flags]
+
+Comandos (en orden de pipeline):
+  clean       inspección + filtrado (películas con ≥5 ratings)
+  remap       userId/movieId → índices densos + triplets
+  movies      catálogo iIdx → título (año), géneros (index/items.csv)
+  features    tags.csv / genome-*.csv → CSR ítem×feature (features/)
+  normalize   medias y CSR centrados (--axis=user|item|both)
+  sim         similitudes Top-K (--metric, --mode, --concurrent)
+  recommend   predicción + evaluación hold-out
+  pipeline    todas las anteriores, rehaciendo solo lo que cambió
+  neighbors   vecinos de un ítem con títulos (requiere movies)
+
+--data y --artifacts son globales: se aceptan antes del comando o entre
+sus flags, y todas las rutas de entrada/salida se resuelven bajo ellas
+(ver internal/layout). Cada corrida queda registrada en
+<artifacts>/manifest.json (ver internal/pipeline).
+
+Instalación:
+  go build -o pc3 ./cmd
+*/
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"pc3/internal/layout"
+	"pc3/internal/pipeline"
+)
+
+type command struct {
+	name   string
+	help   string
+	stage  stageFunc                                   // etapas del pipeline
+	config bool                                        // acepta --config (sección del experimento)
+	run    func(l *layout.Layout, args []string) error // otros comandos
+}
+
+// stageCommands en orden de pipeline (así se listan en la ayuda y así los
+// encadena pc3 pipeline).
+var stageCommands = []command{
+	{name: "clean", help: "inspección + filtrado (≥5 ratings por película)", stage: cleanStage},
+	{name: "remap", help: "índices densos + triplets (uIdx,iIdx,rating)", stage: remapStage},
+	{name: "movies", help: "títulos, años y géneros de movies.csv unidos a iIdx", stage: moviesStage},
+	{name: "features", help: "tags y genome → CSR ítem×feature (features/)", stage: featuresStage},
+	{name: "normalize", help: "medias + CSR centrados por usuario/ítem", stage: normalizeStage},
+	{name: "sim", help: "similitudes Top-K (--metric, --mode, --concurrent)", stage: simStage, config: true},
+	{name: "recommend", help: "predicción + evaluación (MAE, RMSE, métricas top-K)", stage: recommendStage, config: true},
+}
+
+var commands = append(slices.Clone(stageCommands),
+	command{name: "pipeline", help: "corre las etapas que cambiaron (manifest incremental)", run: runPipeline},
+	command{name: "neighbors", help: "vecinos Top-K de un ítem con títulos (--sim, --item)", run: runNeighbors},
+)
+
+func lookup(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// globalExplicit son los flags globales pasados antes del comando.
+var globalExplicit = map[string]bool{}
+
+// explicitFlags une los flags pasados antes del comando con los de fs.
+func explicitFlags(fs *flag.FlagSet) map[string]bool {
+	out := maps.Clone(globalExplicit)
+	fs.Visit(func(f *flag.Flag) { out[f.Name] = true })
+	return out
+}
+
+func main() {
+	l := layout.Default()
+	global := flag.NewFlagSet("pc3", flag.ExitOnError)
+	globalFlags(global, &l)
+	global.Usage = usage
+	_ = global.Parse(os.Args[1:])
+	global.Visit(func(f *flag.Flag) { globalExplicit[f.Name] = true })
+
+	if global.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	name := global.Arg(0)
+	cmd, ok := lookup(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "comando desconocido %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	run := cmd.run
+	if run == nil {
+		run = stageCommand(cmd)
+	}
+	if err := run(&l, global.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Uso: pc3 [--data=DIR] [--artifacts=DIR] <comando> [flags]\n\nComandos:\n")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.help)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags globales:\n")
+	fs := flag.NewFlagSet("pc3", flag.ContinueOnError)
+	globalFlags(fs, &layout.Layout{})
+	fs.SetOutput(os.Stderr)
+	fs.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nAyuda por comando: pc3 <comando> --help\n")
+}
+
+// globalFlags registra --data/--artifacts en fs; el valor por defecto es el
+// que ya tenga l, así el comando hereda lo pasado antes de su nombre.
+func globalFlags(fs *flag.FlagSet, l *layout.Layout) {
+	def := layout.Default()
+	if l.Data == "" {
+		l.Data = def.Data
+	}
+	if l.Artifacts == "" {
+		l.Artifacts = def.Artifacts
+	}
+	fs.StringVar(&l.Data, "data", l.Data, "directorio con ratings.csv y movies.csv")
+	fs.StringVar(&l.Artifacts, "artifacts", l.Artifacts, "raíz de salidas del pipeline")
+}
+
+// subcommand crea el FlagSet de un comando con los flags globales incluidos.
+func subcommand(name string, l *layout.Layout) *flag.FlagSet {
+	fs := flag.NewFlagSet("pc3 "+name, flag.ExitOnError)
+	globalFlags(fs, l)
+	return fs
+}
+
+// stageCommand corre una sola etapa siempre (sin mirar si está al día),
+// pero la registra en el manifest para que pc3 pipeline la reconozca.
+func stageCommand(c command) func(l *layout.Layout, args []string) error {
+	return func(l *layout.Layout, args []string) error {
+		fs := subcommand(c.name, l)
+		build := c.stage(fs, l)
+		var config string
+		if c.config {
+			fs.StringVar(&config, "config", "", "archivo JSON de experimento (ver internal/experiment)")
+		}
+		_ = fs.Parse(args)
+		cfg, err := loadConfig(config, l, explicitFlags(fs), map[string]*flag.FlagSet{c.name: fs})
+		if err != nil {
+			return err
+		}
+		st, err := build()
+		if err != nil {
+			return err
+		}
+		if c.config {
+			recordConfig(&st, fs, *l, cfg)
+		}
+		m, err := pipeline.LoadManifest(l.Manifest())
+		if err != nil {
+			return err
+		}
+		r := &pipeline.Runner{Manifest: m, Force: true}
+		return r.Run([]pipeline.Stage{st})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+/*
+pc3 neighbors — vecinos Top-K de un ítem, con títulos
+
+  pc3 neighbors --sim=item_topk_cosine.csv --item="pulp fiction" [--n=10]
+
+--item acepta un movieId o parte del título; si hay varias coincidencias se
+muestran las primeras --max_matches. Requiere index/items.csv (pc3 movies).
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"pc3/internal/catalog"
+	"pc3/internal/layout"
+)
+
+type neighbor struct {
+	j int
+	s float64
+}
+
+func runNeighbors(l *layout.Layout, args []string) error {
+	fs := subcommand("neighbors", l)
+	simName := fs.String("sim", "", "CSV item_topk_*.csv (un nombre sin directorio se busca en <artifacts>/sim/)")
+	item := fs.String("item", "", "movieId o parte del título")
+	n := fs.Int("n", 10, "vecinos a mostrar por ítem")
+	maxMatches := fs.Int("max_matches", 5, "coincidencias de --item a mostrar")
+	_ = fs.Parse(args)
+	if *simName == "" || *item == "" {
+		return errors.New("--sim y --item son requeridos")
+	}
+
+	cat, err := catalog.Load(l.Items())
+	if err != nil {
+		return fmt.Errorf("%w (correr pc3 movies)", err)
+	}
+	matches := cat.Find(*item)
+	if len(matches) == 0 {
+		return fmt.Errorf("ningún ítem coincide con %q", *item)
+	}
+	if len(matches) > *maxMatches {
+		fmt.Printf("%d coincidencias; se muestran las primeras %d\n", len(matches), *maxMatches)
+		matches = matches[:*maxMatches]
+	}
+
+	want := map[int][]neighbor{}
+	for _, i := range matches {
+		want[i] = nil
+	}
+	if err := readNeighbors(l.SimFile(*simName), want); err != nil {
+		return err
+	}
+
+	for _, i := range matches {
+		fmt.Printf("%s  [iIdx=%d]\n", cat.Label(i), i)
+		list := want[i]
+		if len(list) == 0 {
+			fmt.Printf("  (sin vecinos)\n")
+		}
+		for _, nb := range list[:min(*n, len(list))] {
+			fmt.Printf("  %.4f  %s\n", nb.s, cat.Label(nb.j))
+		}
+	}
+	return nil
+}
+
+// readNeighbors completa want[i] con las filas (i,j,sim) del CSV Top-K, en
+// el orden del archivo (descendente por similitud).
+func readNeighbors(path string, want map[int][]neighbor) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	r.ReuseRecord = true
+	header, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("leer cabecera de %s: %w", path, err)
+	}
+	if header[0] != "iIdx" {
+		return fmt.Errorf("%s no es un Top-K de ítems (cabecera %v)", path, header)
+	}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil || len(rec) < 3 {
+			continue
+		}
+		i, _ := strconv.Atoi(rec[0])
+		if _, ok := want[i]; !ok {
+			continue
+		}
+		j, _ := strconv.Atoi(rec[1])
+		s, _ := strconv.ParseFloat(rec[2], 64)
+		want[i] = append(want[i], neighbor{j, s})
+	}
+}
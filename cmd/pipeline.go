@@ -0,0 +1,135 @@
+package main
+
+/*
+pc3 pipeline — clean → remap → movies → features → normalize → sim → recommend
+
+Acepta los flags de todas las etapas (más --from/--to para acotar) y solo
+corre las que no están al día según <artifacts>/manifest.json. Si no se pasa
+--sim, recommend usa la salida de la etapa sim; si no se pasa --model, usa
+el --mode de sim, y si sim lee --input=implicit, recommend corre con
+--implicit.
+
+Ejemplo: cambiar solo --k_eval rehace recommend y nada más.
+  pc3 pipeline --metric=cosine --concurrent --shrink=20 --positive --k_eval=20
+*/
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+
+	"pc3/internal/layout"
+	"pc3/internal/pipeline"
+)
+
+func runPipeline(l *layout.Layout, args []string) error {
+	all := subcommand("pipeline", l)
+	from := all.String("from", "clean", "primera etapa a considerar")
+	to := all.String("to", "recommend", "última etapa a considerar")
+	force := all.Bool("force", false, "correr las etapas aunque estén al día")
+	dryRun := all.Bool("dry_run", false, "solo mostrar qué se correría")
+	config := all.String("config", "", "archivo JSON de experimento (secciones sim y recommend)")
+
+	// cada etapa registra sus flags en su propio FlagSet (para saber cuáles
+	// son sus parámetros) y se exponen todos en el FlagSet del pipeline; un
+	// flag que registran varias etapas (p. ej. --mem_limit) llega a todas
+	var names []string
+	sets := map[string]*flag.FlagSet{}
+	builds := map[string]func() (pipeline.Stage, error){}
+	flags := map[string]shared{}
+	var order []*flag.Flag
+	for _, c := range stageCommands {
+		fs := subcommand(c.name, l)
+		builds[c.name] = c.stage(fs, l)
+		fs.VisitAll(func(f *flag.Flag) {
+			if all.Lookup(f.Name) != nil {
+				return // propios del pipeline
+			}
+			if _, ok := flags[f.Name]; !ok {
+				order = append(order, f)
+			}
+			flags[f.Name] = append(flags[f.Name], f.Value)
+		})
+		names = append(names, c.name)
+		sets[c.name] = fs
+	}
+	for _, f := range order {
+		all.Var(flags[f.Name], f.Name, f.Usage)
+	}
+	_ = all.Parse(args)
+
+	lo, hi := slices.Index(names, *from), slices.Index(names, *to)
+	if lo < 0 || hi < 0 || lo > hi {
+		return fmt.Errorf("--from/--to deben ser etapas en orden (%v)", names)
+	}
+
+	explicit := explicitFlags(all)
+	cfg, err := loadConfig(*config, l, explicit, sets)
+	if err != nil {
+		return err
+	}
+
+	stages := make([]pipeline.Stage, 0, len(names))
+	for _, c := range stageCommands {
+		name := c.name
+		st, err := builds[name]()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if name == "sim" {
+			// recommend evalúa lo que produjo sim salvo que se indique otra
+			// cosa (en la línea de comandos o en el experimento)
+			rec := sets["recommend"]
+			if _, ok := cfg.Section("recommend")["sim"]; !ok && !explicit["sim"] {
+				_ = rec.Set("sim", st.Outputs[0])
+			}
+			if _, ok := cfg.Section("recommend")["model"]; !ok && !explicit["model"] {
+				_ = rec.Set("model", sets["sim"].Lookup("mode").Value.String())
+			}
+			// sobre pesos implícitos no hay rating que predecir
+			if _, ok := cfg.Section("recommend")["implicit"]; !ok && !explicit["implicit"] &&
+				sets["sim"].Lookup("input").Value.String() == "implicit" {
+				_ = rec.Set("implicit", "true")
+			}
+		}
+		if c.config {
+			recordConfig(&st, sets[name], *l, cfg)
+		}
+		stages = append(stages, st)
+	}
+
+	m, err := pipeline.LoadManifest(l.Manifest())
+	if err != nil {
+		return err
+	}
+	r := &pipeline.Runner{Manifest: m, Force: *force, DryRun: *dryRun}
+	return r.Run(stages[lo : hi+1])
+}
+
+// shared reparte un flag del pipeline entre las etapas que lo registran.
+type shared []flag.Value
+
+func (s shared) String() string {
+	if len(s) == 0 { // flag.PrintDefaults lo llama sobre el valor cero
+		return ""
+	}
+	return s[0].String()
+}
+
+func (s shared) Set(v string) error {
+	for _, x := range s {
+		if err := x.Set(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsBoolFlag permite --flag sin valor cuando el flag original es booleano.
+func (s shared) IsBoolFlag() bool {
+	if len(s) == 0 {
+		return false
+	}
+	b, ok := s[0].(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
@@ -0,0 +1,275 @@
+package main
+
+/*
+pc3 sim — similitudes Top-K (reemplaza cmd/algorithms y cmd/concurrent)
+
+  --metric=pearson|cosine|jaccard|adjusted_cosine|spearman|msd|constrained_pearson|conditional
+                                    (registro de internal/similarity)
+  --mode=item|user
+  --concurrent                      driver concurrente (--workers goroutines), para
+                                    ambos modos y todas las métricas; en mode=user
+                                    reparte las cestas de ítems entre los workers
+
+Entrada (--input=auto):
+  mode=item -> <artifacts>/ratings_ui.bin             (ratings crudos; ratings_ui.csv si no existe)
+  mode=user -> <artifacts>/matrix_user_csr/*          (r' = r - μ_u, mmap)
+  --input=raw -> matrix_raw_csr (mode=item) o matrix_raw_csc (mode=user), de
+    normalize --raw: ratings crudos ya orientados por cesta, sin transponer.
+    Con --input=triplets (o auto en mode=item) se usan en lugar de
+    ratings_ui.bin/.csv si existen: mismos valores, sin parsear ni armar la matriz.
+  --input=baseline_csr -> <artifacts>/matrix_baseline_csr/* (r - μ - b_u - b_i,
+    de normalize --axis=baseline; la métrica se guarda como <metric>_baseline)
+  adjusted_cosine espera ratings centrados por cesta: con --input=auto lee
+    matrix_user_csr (mode=item, r - μ_u) o matrix_item_csr (mode=user).
+  msd y constrained_pearson necesitan ratings crudos: con --input=auto leen
+    los triplets también en mode=user (o matrix_raw_csc si existe).
+  --input=implicit -> matrix_implicit_csr (mode=item) o matrix_implicit_csc
+    (mode=user), pesos binarios o de confianza de normalize --axis=implicit;
+    la métrica se guarda como <metric>_implicit (ver recommend --implicit)
+
+--user_weight=none|iuf|bm25 pondera cada cesta al acumular (IUF log(N/n) o
+normalización por largo de BM25) para que los usuarios con miles de ratings
+no dominen las co-ocurrencias; la salida lleva el sufijo _iuf o _bm25 y el
+peso queda en el reporte (ver internal/similarity/weights.go).
+
+--metric=conditional (solo mode=item) es asimétrica: sim(i→j) =
+f(ij) / (f(i)·f(j)^α), la probabilidad condicional de Deshpande–Karypis con
+--cond_alpha (0.5) castigando a los ítems populares. Cada fila i,j,sim del
+CSV es sim(i→j), así que el par aparece en ambas direcciones con puntajes
+distintos. (No es --alpha: ese es el de normalize --implicit_mode=confidence
+y en pc3 pipeline los flags con el mismo nombre se comparten.)
+
+Salidas:
+  <artifacts>/sim/<mode>_topk_<metric>[_iuf|_bm25][_conc].csv
+  <artifacts>/sim/<mode>_<metric>[_iuf|_bm25][_conc]_report.txt (+ .json)
+    (mode=item: con index/items.csv de `pc3 movies`, el reporte incluye
+    vecinos de ejemplo con títulos)
+
+Las versiones concurrentes históricas usaban --shrink=20 y --positive;
+aquí ambos drivers comparten los mismos valores por defecto y se pasan
+explícitos (ver instrucciones.txt).
+*/
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"pc3/internal/catalog"
+	"pc3/internal/csr"
+	"pc3/internal/layout"
+	"pc3/internal/pipeline"
+	"pc3/internal/preprocess"
+	"pc3/internal/ratings"
+	"pc3/internal/report"
+	"pc3/internal/similarity"
+)
+
+func simStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	var opt similarity.Options
+	var metric, modeStr, input string
+	var concurrent bool
+
+	fs.StringVar(&metric, "metric", "pearson", strings.Join(similarity.Names(), " | "))
+	fs.StringVar(&modeStr, "mode", "item", "user | item")
+	fs.BoolVar(&concurrent, "concurrent", false, "usar el driver concurrente")
+	fs.StringVar(&input, "input", "auto", "auto | triplets | raw | user_csr | item_csr | baseline_csr | implicit")
+	fs.IntVar(&opt.K, "k", 20, "Top-K vecinos por nodo")
+	fs.IntVar(&opt.MinCo, "min_co", 3, "mínimo co-ocurrencias")
+	fs.IntVar(&opt.PctUsers, "pct_users", 100, "% de usuarios (0-100)")
+	fs.IntVar(&opt.PctItems, "pct_items", 100, "% de ítems (0-100)")
+	fs.IntVar(&opt.Shrink, "shrink", 0, "parámetro de shrinkage (0 = sin shrinkage)")
+	fs.BoolVar(&opt.Positive, "positive", false, "descartar similitudes <= 0")
+	fs.IntVar(&opt.Workers, "workers", 8, "número de goroutines (solo --concurrent)")
+	fs.StringVar(&opt.UserWeight, "user_weight", similarity.WeightNone, "peso de cesta: none | iuf | bm25")
+	fs.Float64Var(&opt.CondAlpha, "cond_alpha", 0.5, "solo metric=conditional: castigo a la popularidad, sim(i→j) = P(j|i)/P(j)^α")
+
+	return func() (pipeline.Stage, error) {
+		s, err := similarity.ByName(metric)
+		if err != nil {
+			return pipeline.Stage{}, err
+		}
+		mode, err := similarity.ParseMode(modeStr)
+		if err != nil {
+			return pipeline.Stage{}, err
+		}
+		opt.Mode = mode
+		if opt.UserWeight, err = similarity.ParseUserWeight(opt.UserWeight); err != nil {
+			return pipeline.Stage{}, err
+		}
+		skip := []string{"workers"} // workers no cambia el resultado
+		if similarity.IsDirected(s) {
+			if mode != similarity.ModeItem {
+				return pipeline.Stage{}, fmt.Errorf("--metric=%s solo admite --mode=item", s.Name())
+			}
+		} else {
+			opt.CondAlpha = 0
+			skip = append(skip, "cond_alpha")
+		}
+		in, err := simInput(*l, mode, input, s)
+		if err != nil {
+			return pipeline.Stage{}, err
+		}
+		// sobre residuos o pesos implícitos el nombre lleva sufijo para no
+		// pisar la salida usual
+		name := s.Name()
+		switch input {
+		case "baseline_csr":
+			name += "_baseline"
+		case "implicit":
+			name += "_implicit"
+		}
+		if opt.UserWeight != similarity.WeightNone {
+			name += "_" + opt.UserWeight
+		}
+		out := l.SimTopK(string(mode), name, concurrent)
+		reportPath := l.SimReport(string(mode), name, concurrent)
+		var opts []string
+		if in == l.Triplets() {
+			// se leen en lugar del CSV si existen
+			opts = append(opts, rawInput(*l, mode), l.TripletsBin())
+		}
+		if mode == similarity.ModeItem {
+			opts = append(opts, l.Items()) // títulos en el reporte
+		}
+		return pipeline.Stage{
+			Name:    "sim",
+			Key:     strings.TrimSuffix(l.Rel(out), ".csv"),
+			Inputs:  []string{in},
+			Opt:     opts,
+			Outputs: []string{out, reportPath, report.Path(reportPath)},
+			Params:  params(fs, skip...),
+			Report:  reportPath,
+			Run: func() error {
+				in := in
+				if in == l.Triplets() {
+					if raw := rawInput(*l, mode); exists(raw) {
+						in = raw
+					} else if exists(l.TripletsBin()) {
+						in = l.TripletsBin()
+					}
+				}
+				items := ""
+				if mode == similarity.ModeItem && exists(l.Items()) {
+					items = l.Items()
+				}
+				return runSim(in, items, s, opt, concurrent, out, reportPath)
+			},
+		}, nil
+	}
+}
+
+// runSim calcula el Top-K; si items no es vacío, el reporte muestra
+// vecinos de ejemplo con sus títulos.
+func runSim(in, items string, s similarity.Similarity, opt similarity.Options, concurrent bool, out, reportPath string) error {
+	t0 := time.Now()
+	var m *csr.Matrix
+	var err error
+	switch {
+	case strings.HasSuffix(in, ".bin"):
+		m, err = ratings.LoadBinary(in)
+	case strings.HasSuffix(in, ".csv"):
+		m, err = ratings.LoadCSV(in)
+	default:
+		m, err = csr.Open(in)
+	}
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+	tLoad := time.Since(t0)
+
+	run := similarity.Run
+	if concurrent {
+		run = similarity.RunConcurrent
+	}
+	res, err := run(m, s, opt)
+	if err != nil {
+		return err
+	}
+	res.TLoad = tLoad
+	if items != "" {
+		cat, err := catalog.Load(items)
+		if err != nil {
+			return err
+		}
+		res.Labels = cat.Label
+	}
+
+	if err := res.WriteCSV(out); err != nil {
+		return err
+	}
+	rep := res.Report(out)
+	if err := os.MkdirAll(filepath.Dir(reportPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(reportPath, []byte(rep), 0o644); err != nil {
+		return err
+	}
+	jr := res.JSON(out)
+	jr.Input("matrix", in) // qué se leyó: CSR crudo, .bin o CSV
+	if err := jr.Write(report.Path(reportPath)); err != nil {
+		return err
+	}
+	fmt.Print(rep)
+	fmt.Printf("[OK] %s -> %s\n", s.Name(), out)
+	return nil
+}
+
+// simInput resuelve --input a los triplets (CSV; Run prefiere el .bin) o a
+// un directorio CSR. Si la métrica pide valores centrados por cesta, auto
+// elige el CSR centrado por cesta; si pide ratings crudos, los triplets (o
+// el CSR crudo). Las entradas incompatibles se rechazan.
+func simInput(l layout.Layout, mode similarity.Mode, input string, s similarity.Similarity) (string, error) {
+	centered, raw := similarity.NeedsCentered(s), similarity.NeedsRaw(s)
+	basketCSR := "user_csr" // cestas = usuarios
+	if mode == similarity.ModeUser {
+		basketCSR = "item_csr"
+	}
+	if input == "auto" {
+		input = "triplets"
+		if mode == similarity.ModeUser && !raw {
+			input = "user_csr"
+		}
+		if centered {
+			input = basketCSR
+		}
+	}
+	if centered && input != basketCSR && input != "baseline_csr" {
+		return "", fmt.Errorf("%s espera ratings centrados por cesta: con --mode=%s usar --input=%s o baseline_csr (recibido %q)", s.Name(), mode, basketCSR, input)
+	}
+	if raw && input != "triplets" && input != "raw" {
+		return "", fmt.Errorf("%s necesita ratings sin centrar: usar --input=triplets o raw (recibido %q)", s.Name(), input)
+	}
+	switch input {
+	case "triplets":
+		return l.Triplets(), nil
+	case "raw":
+		return rawInput(l, mode), nil
+	case "user_csr":
+		return l.CSRDir(csr.AxisUser), nil
+	case "item_csr":
+		return l.CSRDir(csr.AxisItem), nil
+	case "baseline_csr":
+		return l.CSRDir(preprocess.AxisBaseline), nil
+	case "implicit":
+		// como raw: filas = cestas de mode
+		if mode == similarity.ModeUser {
+			return l.ImplicitDir(csr.AxisItem), nil
+		}
+		return l.ImplicitDir(csr.AxisUser), nil
+	}
+	return "", fmt.Errorf("--input debe ser auto, triplets, raw, user_csr, item_csr, baseline_csr o implicit (recibido %q)", input)
+}
+
+// rawInput es el CSR crudo cuyas filas son las cestas de mode: usuarios
+// (matrix_raw_csr) para mode=item, ítems (matrix_raw_csc) para mode=user.
+func rawInput(l layout.Layout, mode similarity.Mode) string {
+	if mode == similarity.ModeUser {
+		return l.RawDir(csr.AxisItem)
+	}
+	return l.RawDir(csr.AxisUser)
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"pc3/internal/csr"
+	"pc3/internal/experiment"
+	"pc3/internal/importers"
+	"pc3/internal/layout"
+	"pc3/internal/pipeline"
+	"pc3/internal/preprocess"
+	"pc3/internal/recommend"
+	"pc3/internal/report"
+	"pc3/utils"
+)
+
+// stageFunc registra los flags de una etapa en fs y devuelve cómo armarla
+// una vez parseados (las rutas dependen de los valores de los flags).
+type stageFunc func(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error)
+
+// params toma los flags de la etapa que afectan el resultado; los globales
+// ya quedan reflejados en las rutas.
+func params(fs *flag.FlagSet, skip ...string) map[string]string {
+	p := map[string]string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "data" || f.Name == "artifacts" || f.Name == "config" || slices.Contains(skip, f.Name) {
+			return
+		}
+		p[f.Name] = f.Value.String()
+	})
+	return p
+}
+
+// memLimitFlag registra --mem_limit (remap y normalize lo comparten).
+func memLimitFlag(fs *flag.FlagSet) *string {
+	return fs.String("mem_limit", "0", "memoria para ordenar triplets (ej. 512MB, 2GB); 0 = sin límite, todo en RAM")
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+func cleanStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	opt := preprocess.DefaultCleanOptions()
+	fs.IntVar(&opt.MinItemRatings, "min_item_ratings", opt.MinItemRatings, "conservar películas con ≥N ratings")
+	fs.IntVar(&opt.MinUserRatings, "min_user_ratings", opt.MinUserRatings, "conservar usuarios con ≥N ratings (0 = sin filtro)")
+	fs.BoolVar(&opt.KCore, "kcore", opt.KCore, "repetir el filtrado hasta que ningún usuario/película quede bajo su umbral")
+	fs.StringVar(&opt.Dedup, "dedup", opt.Dedup, "duplicados (userId, movieId): "+strings.Join(preprocess.DedupPolicies, " | "))
+	fs.StringVar(&opt.Format, "format", opt.Format, "formato de origen: "+preprocess.FormatMovieLens+" | "+strings.Join(importers.Names(), " | "))
+	fs.StringVar(&opt.Source, "source", "", "archivo de origen (por defecto ratings.csv, u.data o ratings.dat en --data según --format)")
+	fs.StringVar(&opt.Columns, "columns", "", "csv/tsv/jsonl: rol=columna (ej. user=reviewerID,item=asin,rating=overall,timestamp=unixReviewTime)")
+	fs.BoolVar(&opt.Header, "header", opt.Header, "csv/tsv: la primera fila es cabecera (si no, --columns usa posiciones)")
+	fs.BoolVar(&opt.ExcludeSuspicious, "exclude_suspicious", false, "quitar los usuarios de suspicious_users.csv antes del filtrado")
+	so := &opt.Suspicious
+	fs.IntVar(&so.MinRatings, "susp_min_ratings", so.MinRatings, "ratings mínimos para evaluar entropía y desvío")
+	fs.Float64Var(&so.Entropy, "susp_entropy", so.Entropy, "señal entropy: entropía de los ratings ≤ X bits")
+	fs.Float64Var(&so.Std, "susp_std", so.Std, "señal variance: desvío de los ratings ≤ X")
+	fs.Int64Var(&so.Window, "susp_window", so.Window, "ventana en segundos de la señal burst")
+	fs.IntVar(&so.Burst, "susp_burst", so.Burst, "señal burst: ≥N ratings dentro de --susp_window")
+	fs.IntVar(&so.Volume, "susp_volume", so.Volume, "señal volume: ≥N ratings")
+	fs.IntVar(&so.Score, "susp_score", so.Score, "score mínimo (entropy + variance + 2·burst + volume) para marcar un usuario")
+	return func() (pipeline.Stage, error) {
+		if err := opt.Suspicious.Validate(); err != nil {
+			return pipeline.Stage{}, err
+		}
+		src, err := preprocess.RatingsSource(*l, opt)
+		if err != nil {
+			return pipeline.Stage{}, err
+		}
+		outputs := []string{
+			l.CleanReport(), report.Path(l.CleanReport()),
+			l.Filtered(),
+			l.FilterReport(), report.Path(l.FilterReport()),
+			l.Suspicious(),
+		}
+		if opt.Format != preprocess.FormatMovieLens {
+			outputs = append(outputs, l.Imported())
+		}
+		return pipeline.Stage{
+			Name:    "clean",
+			Key:     "clean",
+			Inputs:  []string{src},
+			Opt:     []string{l.Movies()}, // solo se usa para contar películas
+			Outputs: outputs,
+			Params:  params(fs),
+			Report:  l.CleanReport(),
+			Run:     func() error { return preprocess.Clean(*l, opt) },
+		}, nil
+	}
+}
+
+func remapStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	var opt preprocess.RemapOptions
+	fs.BoolVar(&opt.Incremental, "incremental", false, "conservar los índices de index/*.csv y solo agregar ids nuevos")
+	fs.StringVar(&opt.RatingType, "rating_type", "auto", "ratings en ratings_ui.bin: auto | float32 | uint8 (medias estrellas)")
+	memLimit := memLimitFlag(fs)
+	return func() (pipeline.Stage, error) {
+		var err error
+		if opt.MemLimit, err = utils.ParseSize(*memLimit); err != nil {
+			return pipeline.Stage{}, fmt.Errorf("--mem_limit: %w", err)
+		}
+		return pipeline.Stage{
+			Name:    "remap",
+			Key:     "remap",
+			Inputs:  []string{l.Filtered()},
+			Outputs: []string{l.Triplets(), l.TripletsBin(), l.UserMap(), l.ItemMap(), l.RemapReport(), report.Path(l.RemapReport())},
+			Params:  params(fs, "mem_limit"), // solo cambia cómo se ordena
+			Report:  l.RemapReport(),
+			Run:     func() error { return preprocess.Remap(*l, opt) },
+		}, nil
+	}
+}
+
+func moviesStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	return func() (pipeline.Stage, error) {
+		return pipeline.Stage{
+			Name:    "movies",
+			Key:     "movies",
+			Inputs:  []string{l.ItemMap()},
+			Opt:     []string{l.Movies()}, // sin movies.csv el catálogo queda sin títulos
+			Outputs: []string{l.Items(), l.MoviesReport(), report.Path(l.MoviesReport())},
+			Params:  params(fs),
+			Report:  l.MoviesReport(),
+			Run:     func() error { return preprocess.Movies(*l) },
+		}, nil
+	}
+}
+
+func featuresStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	var opt preprocess.FeaturesOptions
+	fs.Float64Var(&opt.MinRelevance, "min_relevance", 0, "genome: descartar relevancias menores (0 = todas las no nulas)")
+	fs.IntVar(&opt.MinTagUsers, "min_tag_users", 2, "tags de usuarios: usuarios distintos mínimos para entrar al vocabulario")
+	return func() (pipeline.Stage, error) {
+		// los archivos de tags son opcionales: se generan las salidas de los
+		// que existan, y agregarlos después invalida la etapa
+		outputs := []string{l.FeaturesReport(), report.Path(l.FeaturesReport())}
+		if exists(l.GenomeScores()) {
+			outputs = append(outputs, l.GenomeCSR(), l.GenomeVocab())
+		}
+		if exists(l.Tags()) {
+			outputs = append(outputs, l.TagsCSR(), l.TagVocab())
+		}
+		return pipeline.Stage{
+			Name:    "features",
+			Key:     "features",
+			Inputs:  []string{l.ItemMap()},
+			Opt:     []string{l.GenomeScores(), l.GenomeTags(), l.Tags()},
+			Outputs: outputs,
+			Params:  params(fs),
+			Report:  l.FeaturesReport(),
+			Run:     func() error { return preprocess.Features(*l, opt) },
+		}, nil
+	}
+}
+
+func normalizeStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	var opt preprocess.NormalizeOptions
+	fs.StringVar(&opt.Axis, "axis", "both", "user | item | both | baseline | implicit")
+	fs.StringVar(&opt.Norm, "norm", preprocess.NormCenter, strings.Join(preprocess.Norms, " | ")+" (normalización por fila; no aplica a baseline ni implicit)")
+	fs.BoolVar(&opt.TS, "ts", false, "guardar los timestamps como ts.bin en cada CSR")
+	fs.BoolVar(&opt.Raw, "raw", false, "además matrix_raw_csr y matrix_raw_csc con los ratings sin centrar")
+	fs.Float64Var(&opt.RegUser, "reg_user", 10, "solo axis=baseline: regularización λ de los sesgos de usuario")
+	fs.Float64Var(&opt.RegItem, "reg_item", 25, "solo axis=baseline: regularización λ de los sesgos de ítem")
+	fs.IntVar(&opt.Iters, "iters", 10, "solo axis=baseline: iteraciones de mínimos cuadrados alternados")
+	fs.StringVar(&opt.ImplicitMode, "implicit_mode", preprocess.ImplicitBinary, "solo axis=implicit: binary (1 si rating ≥ --threshold) | confidence (1 + α·rating)")
+	fs.Float64Var(&opt.Threshold, "threshold", 3.5, "solo axis=implicit, binary: rating mínimo de una interacción positiva")
+	fs.Float64Var(&opt.Alpha, "alpha", 1, "solo axis=implicit, confidence: α del peso 1 + α·rating")
+	memLimit := memLimitFlag(fs)
+	return func() (pipeline.Stage, error) {
+		var err error
+		if opt.MemLimit, err = utils.ParseSize(*memLimit); err != nil {
+			return pipeline.Stage{}, fmt.Errorf("--mem_limit: %w", err)
+		}
+		if !preprocess.ValidNorm(opt.Norm) {
+			return pipeline.Stage{}, fmt.Errorf("--norm debe ser center, zscore o percentile (recibido %q)", opt.Norm)
+		}
+		skip := []string{"mem_limit"}
+		var outputs []string
+		for _, ax := range []string{csr.AxisUser, csr.AxisItem} {
+			if opt.Axis == ax || opt.Axis == "both" {
+				outputs = append(outputs, l.Means(ax), l.CSRDir(ax))
+				switch opt.Norm {
+				case preprocess.NormZScore:
+					outputs = append(outputs, l.Stds(ax))
+				case preprocess.NormPercentile:
+					outputs = append(outputs, l.Ranks(ax))
+				}
+			}
+		}
+		if opt.Raw && outputs != nil {
+			outputs = append(outputs, l.RawDir(csr.AxisUser), l.RawDir(csr.AxisItem))
+		}
+		// los flags de otros ejes no intervienen: que cambiarlos no invalide la etapa
+		switch opt.Axis {
+		case preprocess.AxisBaseline:
+			outputs = []string{l.Baseline(), l.Bias(csr.AxisUser), l.Bias(csr.AxisItem), l.CSRDir(preprocess.AxisBaseline)}
+			skip = append(skip, "norm", "raw", "implicit_mode", "threshold", "alpha")
+		case preprocess.AxisImplicit:
+			outputs = []string{l.Implicit(), l.ImplicitDir(csr.AxisUser), l.ImplicitDir(csr.AxisItem)}
+			skip = append(skip, "norm", "raw", "reg_user", "reg_item", "iters")
+		default:
+			skip = append(skip, "reg_user", "reg_item", "iters", "implicit_mode", "threshold", "alpha")
+		}
+		if outputs == nil {
+			return pipeline.Stage{}, fmt.Errorf("--axis debe ser user, item, both, baseline o implicit (recibido %q)", opt.Axis)
+		}
+		rep := l.NormalizeReport(opt.Axis)
+		outputs = append(outputs, rep, report.Path(rep))
+		return pipeline.Stage{
+			Name:    "normalize",
+			Key:     "normalize/" + opt.Axis,
+			Inputs:  []string{l.Triplets()},
+			Opt:     []string{l.TripletsBin()}, // se lee en lugar del CSV si existe
+			Outputs: outputs,
+			Params:  params(fs, skip...),
+			Report:  rep,
+			Run:     func() error { return preprocess.Normalize(*l, opt) },
+		}, nil
+	}
+}
+
+func recommendStage(fs *flag.FlagSet, l *layout.Layout) func() (pipeline.Stage, error) {
+	var opt recommend.Options
+	fs.StringVar(&opt.Model, "model", "user", "user | item")
+	fs.StringVar(&opt.Sim, "sim", "", "CSV de similitud (un nombre sin directorio se busca en <artifacts>/sim/)")
+	fs.Float64Var(&opt.TestRatio, "test_ratio", 0.1, "proporción de test por usuario")
+	fs.IntVar(&opt.KEval, "k_eval", 0, "si >0, límite de vecinos al predecir")
+	fs.IntVar(&opt.KMetrics, "k_metrics", 20, "K para métricas top-K (precision/recall/NDCG)")
+	fs.Float64Var(&opt.RelTh, "rel_th", 4.0, "rating mínimo para considerar un ítem relevante")
+	fs.BoolVar(&opt.Centered, "centered", false, "solo model=item: true si similitudes se calcularon sobre ratings centrados")
+	fs.StringVar(&opt.Norm, "norm", preprocess.NormCenter, "solo model=user: --norm de normalize con el que se calcularon las similitudes")
+	fs.BoolVar(&opt.Baseline, "baseline", false, "predecir sobre μ + b_u + b_i (requiere normalize --axis=baseline)")
+	fs.BoolVar(&opt.Implicit, "implicit", false, "feedback implícito: rankear ítems no vistos y evaluar solo Top-K (requiere normalize --axis=implicit)")
+	fs.StringVar(&opt.Report, "report", "", "ruta de reporte (opcional)")
+	return func() (pipeline.Stage, error) {
+		if opt.Sim == "" {
+			return pipeline.Stage{}, errors.New("--sim requerido (ruta a user_topk_*.csv o item_topk_*.csv)")
+		}
+		if opt.Report == "" {
+			opt.Report = filepath.Join(l.ReportsDir(), recommend.DefaultReport(opt))
+		}
+		inputs := []string{l.Triplets(), l.SimFile(opt.Sim)}
+		switch {
+		case opt.Implicit:
+			inputs = append(inputs, l.Implicit())
+		case opt.Baseline:
+			inputs = append(inputs, l.Baseline(), l.Bias(csr.AxisUser), l.Bias(csr.AxisItem))
+		case opt.Model == "user":
+			inputs = append(inputs, l.Means(csr.AxisUser))
+			switch opt.Norm {
+			case preprocess.NormZScore:
+				inputs = append(inputs, l.Stds(csr.AxisUser))
+			case preprocess.NormPercentile:
+				inputs = append(inputs, l.Ranks(csr.AxisUser))
+			}
+		}
+
+		return pipeline.Stage{
+			Name:    "recommend",
+			Key:     strings.TrimSuffix(l.Rel(opt.Report), ".txt"),
+			Inputs:  inputs,
+			Opt:     []string{l.TripletsBin(), l.Items()}, // ratings binarios; títulos en los ejemplos
+			Outputs: []string{opt.Report, report.Path(opt.Report)},
+			Params:  params(fs),
+			Report:  opt.Report,
+			Run: func() error {
+				opt.Items = ""
+				if exists(l.Items()) {
+					opt.Items = l.Items()
+				}
+				return recommend.Run(*l, opt)
+			},
+		}, nil
+	}
+}
+
+// loadConfig aplica el experimento de path (si hay) a las raíces y a los
+// flags de cada etapa, sin pisar los que se pasaron explícitamente.
+func loadConfig(path string, l *layout.Layout, explicit map[string]bool, sets map[string]*flag.FlagSet) (*experiment.Config, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cfg, err := experiment.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Dataset.Data != "" && !explicit["data"] {
+		l.Data = cfg.Dataset.Data
+	}
+	if cfg.Dataset.Artifacts != "" && !explicit["artifacts"] {
+		l.Artifacts = cfg.Dataset.Artifacts
+	}
+	l.Experiment = cfg.Name
+	for name, fs := range sets {
+		if err := experiment.Apply(fs, cfg.Section(name), explicit); err != nil {
+			return nil, fmt.Errorf("%s: sección %s: %w", path, name, err)
+		}
+	}
+	return cfg, nil
+}
+
+// recordConfig hace que la etapa guarde su configuración resuelta junto al
+// reporte cuando corre.
+func recordConfig(st *pipeline.Stage, fs *flag.FlagSet, l layout.Layout, cfg *experiment.Config) {
+	report := st.Report
+	if report == "" {
+		return
+	}
+	r := experiment.Resolved{Stage: st.Name, Data: l.Data, Artifacts: l.Artifacts, Flags: experiment.Resolve(fs)}
+	if cfg != nil {
+		r.Experiment, r.Config = cfg.Name, cfg.Path()
+	}
+	run := st.Run
+	st.Run = func() error {
+		if err := run(); err != nil {
+			return err
+		}
+		r.Time = time.Now()
+		return experiment.Write(report, r)
+	}
+	st.Outputs = append(st.Outputs, experiment.ConfigPath(report))
+}
@@ -0,0 +1,213 @@
+package catalog
+
+/*
+CATÁLOGO de ítems (metadata de movies.csv unida a iIdx)
+
+Lo genera `pc3 movies` en <artifacts>/index/items.csv:
+
+  iIdx,movieId,title,year,genres
+  0,296,Pulp Fiction,1994,Comedy|Crime|Drama|Thriller
+
+- title viene sin el año; year = 0 si el título no lo traía.
+- genres separados por '|' (vacío si movies.csv dice "(no genres listed)").
+- Ítems del item_map sin fila en movies.csv quedan con title vacío.
+
+Cualquier comando puede cargarlo con Load (o LoadIfExists si es opcional)
+y mostrar Label(iIdx) = "Pulp Fiction (1994)" en lugar de "296"/"0".
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// NoGenres es el valor de movies.csv para películas sin géneros.
+const NoGenres = "(no genres listed)"
+
+// Item es la metadata de una película.
+type Item struct {
+	IIdx    int
+	MovieID int
+	Title   string
+	Year    int
+	Genres  []string
+}
+
+// Label es "Título (año)", o solo el título si no hay año.
+func (it Item) Label() string {
+	if it.Year == 0 {
+		return it.Title
+	}
+	return fmt.Sprintf("%s (%d)", it.Title, it.Year)
+}
+
+// Catalog indexa los ítems por iIdx.
+type Catalog struct {
+	items []Item
+	ok    []bool
+}
+
+// New arma un catálogo a partir de sus ítems.
+func New(items []Item) *Catalog {
+	c := &Catalog{}
+	for _, it := range items {
+		for len(c.items) <= it.IIdx {
+			c.items = append(c.items, Item{})
+			c.ok = append(c.ok, false)
+		}
+		c.items[it.IIdx], c.ok[it.IIdx] = it, true
+	}
+	return c
+}
+
+// Len es la cantidad de posiciones (máximo iIdx + 1).
+func (c *Catalog) Len() int { return len(c.items) }
+
+// Get devuelve el ítem iIdx, si existe.
+func (c *Catalog) Get(iIdx int) (Item, bool) {
+	if c == nil || iIdx < 0 || iIdx >= len(c.items) || !c.ok[iIdx] {
+		return Item{}, false
+	}
+	return c.items[iIdx], true
+}
+
+// Label describe iIdx para reportes: "Título (año)" si hay metadata, si no
+// "iIdx=N". Es seguro sobre un catálogo nil.
+func (c *Catalog) Label(iIdx int) string {
+	if it, ok := c.Get(iIdx); ok && it.Title != "" {
+		return it.Label()
+	}
+	return fmt.Sprintf("iIdx=%d", iIdx)
+}
+
+// Find busca por movieId o, si q no es un número, por subcadena del título
+// (sin distinguir mayúsculas). Devuelve los iIdx encontrados.
+func (c *Catalog) Find(q string) []int {
+	var out []int
+	if id, err := strconv.Atoi(strings.TrimSpace(q)); err == nil {
+		for k, it := range c.items {
+			if c.ok[k] && it.MovieID == id {
+				out = append(out, k)
+			}
+		}
+		return out
+	}
+	q = strings.ToLower(strings.TrimSpace(q))
+	for k, it := range c.items {
+		if c.ok[k] && strings.Contains(strings.ToLower(it.Label()), q) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// yearRe toma el año final "(1994)" (o un rango "(2006-2007)") del título.
+var yearRe = regexp.MustCompile(`^(.*?)\s*\((\d{4})(?:[-–]\d{0,4})?\)\s*$`)
+
+// ParseTitle separa "Pulp Fiction (1994)" en título y año (0 si no hay).
+func ParseTitle(raw string) (string, int) {
+	raw = strings.TrimSpace(raw)
+	m := yearRe.FindStringSubmatch(raw)
+	if m == nil {
+		return raw, 0
+	}
+	year, _ := strconv.Atoi(m[2])
+	return m[1], year
+}
+
+// ParseGenres separa "Comedy|Crime"; "(no genres listed)" da nil.
+func ParseGenres(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || raw == NoGenres {
+		return nil
+	}
+	return strings.Split(raw, "|")
+}
+
+// Load lee items.csv.
+func Load(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReader(f))
+	if _, err := r.Read(); err != nil {
+		return nil, fmt.Errorf("leer cabecera de %s: %w", path, err)
+	}
+	var items []Item
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if len(rec) < 5 {
+			return nil, fmt.Errorf("%s:%d: se esperan 5 columnas, hay %d", path, line, len(rec))
+		}
+		iIdx, err1 := strconv.Atoi(rec[0])
+		id, err2 := strconv.Atoi(rec[1])
+		year, err3 := strconv.Atoi(rec[3])
+		if err1 != nil || err2 != nil || err3 != nil || iIdx < 0 {
+			return nil, fmt.Errorf("%s:%d: fila inválida %v", path, line, rec)
+		}
+		var genres []string
+		if rec[4] != "" {
+			genres = strings.Split(rec[4], "|")
+		}
+		items = append(items, Item{IIdx: iIdx, MovieID: id, Title: rec[2], Year: year, Genres: genres})
+	}
+	return New(items), nil
+}
+
+// LoadIfExists es Load, pero devuelve (nil, nil) si el archivo no existe:
+// los comandos muestran títulos solo si se corrió `pc3 movies`.
+func LoadIfExists(path string) (*Catalog, error) {
+	c, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return c, err
+}
+
+// Write guarda los ítems en orden de iIdx.
+func Write(path string, c *Catalog) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{"iIdx", "movieId", "title", "year", "genres"})
+	for k, it := range c.items {
+		if !c.ok[k] {
+			continue
+		}
+		_ = w.Write([]string{
+			strconv.Itoa(it.IIdx),
+			strconv.Itoa(it.MovieID),
+			it.Title,
+			strconv.Itoa(it.Year),
+			strings.Join(it.Genres, "|"),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,483 @@
+package csr
+
+/*
+CSR (Compressed Sparse Row) compartido — carga memory-mapped, sin copias
+
+Layout en disco (el que escribe normalize):
+  <dir>/indptr.bin   int64,   len = filas+1   (offsets en indices/data)
+  <dir>/indices.bin  int32,   len = NNZ       (columna de cada valor)
+  <dir>/data.bin     float32, len = NNZ       (valor)
+  <dir>/ts.bin       int64,   len = NNZ       (opcional: timestamp Unix de cada valor)
+  <dir>/meta.json    {"users","items","nnz","axis","variant","dtypes":{...}}
+
+ts.bin solo existe si meta.json declara "ts" en dtypes; va en paralelo a
+indices/data y sirve para splits temporales o ponderación por antigüedad.
+
+Todos los .bin están en little-endian. En hosts little-endian los archivos
+se mapean en memoria (mmap) y se reinterpretan directamente como []int64,
+[]int32 y []float32: no se copia ni se decodifica nada y el SO pagina bajo
+demanda. En hosts big-endian (o sin mmap) se cae a leer y decodificar.
+
+Filas / columnas:
+  - matrix_user_csr: filas = usuarios, columnas = ítems
+  - matrix_item_csr: filas = ítems,    columnas = usuarios
+  - matrix_raw_csr:  filas = usuarios, columnas = ítems     (normalize --raw)
+  - matrix_raw_csc:  filas = ítems,    columnas = usuarios  (la misma matriz
+                     usuario×ítem en orden de columnas)
+  - matrix_implicit_csr / _csc: ídem con pesos implícitos (normalize --axis=implicit)
+  - features/*_csr:  filas = ítems,    columnas = features (meta "features")
+  Si meta.json trae "axis" se usa; si no, se deduce por el largo de indptr.
+
+"variant" dice qué valores guarda data.bin: centered (r - media, o la
+normalización de "norm"), raw (ratings tal cual), residual (r - baseline)
+o los pesos implícitos binary (1 si r ≥ umbral) y confidence (1 + α·r).
+Los directorios escritos antes de este campo no lo traen y son centered.
+*/
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// Nombres de archivo dentro de un directorio CSR.
+const (
+	IndptrFile  = "indptr.bin"
+	IndicesFile = "indices.bin"
+	DataFile    = "data.bin"
+	TSFile      = "ts.bin"
+	MetaFile    = "meta.json"
+)
+
+// Tipos soportados (los que declara meta.json en "dtypes").
+const (
+	DTypeInt64   = "int64"
+	DTypeInt32   = "int32"
+	DTypeFloat32 = "float32"
+)
+
+// Ejes posibles de las filas.
+const (
+	AxisUser = "user"
+	AxisItem = "item"
+)
+
+// Variantes de los valores (campo "variant" de meta.json).
+const (
+	VariantCentered = "centered"
+	VariantRaw      = "raw"
+	VariantResidual = "residual"
+	// pesos implícitos (normalize --axis=implicit)
+	VariantBinary     = "binary"
+	VariantConfidence = "confidence"
+)
+
+// DTypes describe el tipo de cada arreglo binario.
+type DTypes struct {
+	Indptr  string `json:"indptr"`
+	Indices string `json:"indices"`
+	Data    string `json:"data"`
+	TS      string `json:"ts,omitempty"` // vacío si no hay ts.bin
+}
+
+// Meta es el contenido de meta.json.
+type Meta struct {
+	Users int    `json:"users"`
+	Items int    `json:"items"`
+	NNZ   int    `json:"nnz"`
+	Axis  string `json:"axis,omitempty"` // "user" | "item" (filas); opcional
+	// Features (si >0): las columnas son features de contenido (tags) y no
+	// usuarios; las filas son ítems (ver preprocess/features.go).
+	Features int `json:"features,omitempty"`
+	// Norm (si no es vacío): normalización por fila distinta del centrado
+	// por media, "zscore" | "percentile" (ver preprocess/norm.go).
+	Norm    string `json:"norm,omitempty"`
+	Variant string `json:"variant,omitempty"` // VariantCentered | VariantRaw | VariantResidual | VariantBinary | VariantConfidence
+	DTypes  DTypes `json:"dtypes"`
+}
+
+// Matrix es una matriz CSR de solo lectura. Indptr siempre empieza en 0 y
+// sus valores indexan Indices/Data.
+//
+// Si la matriz viene de Open, los slices apuntan a memoria mapeada: no deben
+// modificarse y dejan de ser válidos tras Close (incluidas las vistas
+// creadas con SliceRows).
+type Matrix struct {
+	Meta    Meta
+	Indptr  []int64
+	Indices []int32
+	Data    []float32
+	TS      []int64 // opcional (nil si no hay ts.bin), paralelo a Data
+
+	rows, cols int
+	maps       []*mapping
+}
+
+// Open abre el directorio CSR, mapea los arreglos en memoria y los valida
+// contra meta.json y con Validate: un indptr roto o una columna fuera de
+// rango es un error acá y no un panic en quien recorra las filas.
+func Open(dir string) (*Matrix, error) {
+	mb, err := os.ReadFile(filepath.Join(dir, MetaFile))
+	if err != nil {
+		return nil, fmt.Errorf("leer meta: %w", err)
+	}
+	var mt Meta
+	if err := json.Unmarshal(mb, &mt); err != nil {
+		return nil, fmt.Errorf("parsear %s: %w", MetaFile, err)
+	}
+	if err := checkDTypes(mt.DTypes); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+
+	m := &Matrix{Meta: mt}
+	ok := false
+	defer func() {
+		if !ok {
+			_ = m.Close()
+		}
+	}()
+
+	ip, err := m.mapFile(filepath.Join(dir, IndptrFile), 8)
+	if err != nil {
+		return nil, err
+	}
+	ix, err := m.mapFile(filepath.Join(dir, IndicesFile), 4)
+	if err != nil {
+		return nil, err
+	}
+	dt, err := m.mapFile(filepath.Join(dir, DataFile), 4)
+	if err != nil {
+		return nil, err
+	}
+	m.Indptr = asInt64(ip)
+	m.Indices = asInt32(ix)
+	m.Data = asFloat32(dt)
+	if mt.DTypes.TS != "" {
+		ts, err := m.mapFile(filepath.Join(dir, TSFile), 8)
+		if err != nil {
+			return nil, err
+		}
+		m.TS = asInt64(ts)
+	}
+
+	if len(m.Indptr) == 0 {
+		return nil, fmt.Errorf("%s: indptr vacío", dir)
+	}
+	m.rows = len(m.Indptr) - 1
+	switch {
+	case mt.Axis == AxisUser || (mt.Axis == "" && m.rows == mt.Users):
+		m.Meta.Axis, m.cols = AxisUser, mt.Items
+	case mt.Axis == AxisItem || (mt.Axis == "" && m.rows == mt.Items):
+		m.Meta.Axis, m.cols = AxisItem, mt.Users
+		if mt.Features > 0 {
+			m.cols = mt.Features
+		}
+	default:
+		return nil, fmt.Errorf("%s: indptr tiene %d filas pero meta declara users=%d items=%d",
+			dir, m.rows, mt.Users, mt.Items)
+	}
+	if m.Meta.Axis == AxisUser && m.rows != mt.Users || m.Meta.Axis == AxisItem && m.rows != mt.Items {
+		return nil, fmt.Errorf("%s: axis=%s no coincide con %d filas en indptr", dir, m.Meta.Axis, m.rows)
+	}
+	if len(m.Indices) != mt.NNZ || len(m.Data) != mt.NNZ {
+		return nil, fmt.Errorf("%s: nnz=%d en meta, pero indices=%d data=%d",
+			dir, mt.NNZ, len(m.Indices), len(m.Data))
+	}
+	if m.TS != nil && len(m.TS) != mt.NNZ {
+		return nil, fmt.Errorf("%s: nnz=%d en meta, pero ts=%d", dir, mt.NNZ, len(m.TS))
+	}
+	if m.Indptr[0] != 0 || m.Indptr[m.rows] != int64(mt.NNZ) {
+		return nil, fmt.Errorf("%s: indptr debe ir de 0 a nnz (va de %d a %d)",
+			dir, m.Indptr[0], m.Indptr[m.rows])
+	}
+	if err := m.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+
+	ok = true
+	return m, nil
+}
+
+// New arma una matriz en heap a partir de sus arreglos y valida tamaños.
+// meta.Axis indica qué representan las filas (por defecto, usuarios).
+func New(meta Meta, indptr []int64, indices []int32, data []float32) (*Matrix, error) {
+	if len(indptr) == 0 {
+		return nil, errors.New("csr: indptr vacío")
+	}
+	if len(indices) != len(data) {
+		return nil, fmt.Errorf("csr: indices=%d y data=%d difieren", len(indices), len(data))
+	}
+	rows := len(indptr) - 1
+	if indptr[0] != 0 || indptr[rows] != int64(len(indices)) {
+		return nil, fmt.Errorf("csr: indptr debe ir de 0 a %d (va de %d a %d)", len(indices), indptr[0], indptr[rows])
+	}
+	m := &Matrix{Meta: meta, Indptr: indptr, Indices: indices, Data: data, rows: rows}
+	m.Meta.NNZ = len(indices)
+	m.Meta.DTypes = DTypes{Indptr: DTypeInt64, Indices: DTypeInt32, Data: DTypeFloat32}
+	if m.Meta.Axis == AxisItem {
+		m.Meta.Items, m.cols = rows, meta.Users
+		if meta.Features > 0 {
+			m.cols = meta.Features
+		}
+	} else {
+		m.Meta.Axis = AxisUser
+		m.Meta.Users, m.cols = rows, meta.Items
+	}
+	return m, nil
+}
+
+// SetTS adjunta los timestamps (uno por valor, en el orden de Data); Write
+// los guarda en ts.bin.
+func (m *Matrix) SetTS(ts []int64) error {
+	if len(ts) != len(m.Data) {
+		return fmt.Errorf("csr: ts=%d y data=%d difieren", len(ts), len(m.Data))
+	}
+	m.TS = ts
+	m.Meta.DTypes.TS = DTypeInt64
+	return nil
+}
+
+func checkDTypes(d DTypes) error {
+	if d.Indptr != DTypeInt64 {
+		return fmt.Errorf("dtype de indptr no soportado: %q (se espera %s)", d.Indptr, DTypeInt64)
+	}
+	if d.Indices != DTypeInt32 {
+		return fmt.Errorf("dtype de indices no soportado: %q (se espera %s)", d.Indices, DTypeInt32)
+	}
+	if d.Data != DTypeFloat32 {
+		return fmt.Errorf("dtype de data no soportado: %q (se espera %s)", d.Data, DTypeFloat32)
+	}
+	if d.TS != "" && d.TS != DTypeInt64 {
+		return fmt.Errorf("dtype de ts no soportado: %q (se espera %s)", d.TS, DTypeInt64)
+	}
+	return nil
+}
+
+// Close libera los mapeos. Es seguro llamarlo sobre matrices en heap.
+func (m *Matrix) Close() error {
+	var errs []error
+	for _, mp := range m.maps {
+		if err := mp.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.maps = nil
+	return errors.Join(errs...)
+}
+
+// Rows devuelve el número de filas.
+func (m *Matrix) Rows() int { return m.rows }
+
+// Cols devuelve el número de columnas.
+func (m *Matrix) Cols() int { return m.cols }
+
+// NNZ devuelve el número de valores almacenados.
+func (m *Matrix) NNZ() int { return len(m.Indices) }
+
+// Row devuelve las columnas y valores de la fila r (vistas, sin copia).
+func (m *Matrix) Row(r int) ([]int32, []float32) {
+	lo, hi := m.Indptr[r], m.Indptr[r+1]
+	return m.Indices[lo:hi], m.Data[lo:hi]
+}
+
+// Each recorre todas las filas en orden (incluidas las vacías).
+func (m *Matrix) Each(fn func(r int, idx []int32, val []float32)) {
+	for r := 0; r < m.rows; r++ {
+		idx, val := m.Row(r)
+		fn(r, idx, val)
+	}
+}
+
+// SliceRows devuelve la vista de las filas [lo, hi). Solo se copia indptr
+// (rebasado a 0); indices y data se comparten con la matriz original.
+func (m *Matrix) SliceRows(lo, hi int) *Matrix {
+	if lo < 0 || hi > m.rows || lo > hi {
+		panic(fmt.Sprintf("csr: SliceRows(%d, %d) fuera de rango [0, %d]", lo, hi, m.rows))
+	}
+	base := m.Indptr[lo]
+	indptr := make([]int64, hi-lo+1)
+	for r := range indptr {
+		indptr[r] = m.Indptr[lo+r] - base
+	}
+	end := m.Indptr[hi]
+	out := &Matrix{
+		Meta:    m.Meta,
+		Indptr:  indptr,
+		Indices: m.Indices[base:end],
+		Data:    m.Data[base:end],
+		rows:    hi - lo,
+		cols:    m.cols,
+	}
+	if m.TS != nil {
+		out.TS = m.TS[base:end]
+	}
+	out.Meta.NNZ = int(end - base)
+	if m.Meta.Axis == AxisItem {
+		out.Meta.Items = out.rows
+	} else {
+		out.Meta.Users = out.rows
+	}
+	return out
+}
+
+// Transpose construye la transpuesta en heap (CSR de la otra orientación,
+// es decir, la CSC de m). Las filas de salida quedan ordenadas por columna
+// de entrada y, dentro de cada una, por fila de entrada.
+func (m *Matrix) Transpose() *Matrix {
+	nnz := m.NNZ()
+	indptr := make([]int64, m.cols+1)
+	for _, c := range m.Indices {
+		indptr[c+1]++
+	}
+	for c := 0; c < m.cols; c++ {
+		indptr[c+1] += indptr[c]
+	}
+	indices := make([]int32, nnz)
+	data := make([]float32, nnz)
+	var ts []int64
+	if m.TS != nil {
+		ts = make([]int64, nnz)
+	}
+	pos := make([]int64, m.cols)
+	copy(pos, indptr)
+	for r := 0; r < m.rows; r++ {
+		for p := m.Indptr[r]; p < m.Indptr[r+1]; p++ {
+			c := m.Indices[p]
+			q := pos[c]
+			indices[q] = int32(r)
+			data[q] = m.Data[p]
+			if ts != nil {
+				ts[q] = m.TS[p]
+			}
+			pos[c]++
+		}
+	}
+
+	mt := m.Meta
+	mt.NNZ = nnz
+	if m.Meta.Axis == AxisItem {
+		mt.Axis = AxisUser
+	} else {
+		mt.Axis = AxisItem
+	}
+	return &Matrix{Meta: mt, Indptr: indptr, Indices: indices, Data: data, TS: ts, rows: m.cols, cols: m.rows}
+}
+
+// Validate hace el chequeo completo (O(NNZ)): indptr no decreciente y
+// columnas dentro de [0, Cols). Open lo llama siempre; las matrices armadas
+// con New lo necesitan solo si sus arreglos vienen de afuera.
+func (m *Matrix) Validate() error {
+	for r := 0; r < m.rows; r++ {
+		if m.Indptr[r+1] < m.Indptr[r] {
+			return fmt.Errorf("indptr decrece en la fila %d", r)
+		}
+	}
+	for p, c := range m.Indices {
+		if c < 0 || int(c) >= m.cols {
+			return fmt.Errorf("columna %d fuera de rango en la posición %d (cols=%d)", c, p, m.cols)
+		}
+	}
+	return nil
+}
+
+// ---- mapeo y reinterpretación de bytes ----
+
+// mapFile mapea el archivo (o lo lee si no hay mmap) y comprueba que su
+// tamaño sea múltiplo del ancho del elemento.
+func (m *Matrix) mapFile(path string, width int) ([]byte, error) {
+	var mp *mapping
+	var err error
+	if littleEndian {
+		mp, err = mapReadOnly(path)
+	} else {
+		mp, err = readAll(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	m.maps = append(m.maps, mp)
+	if len(mp.b)%width != 0 {
+		return nil, fmt.Errorf("%s: tamaño %d no es múltiplo de %d bytes", path, len(mp.b), width)
+	}
+	return mp.b, nil
+}
+
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+func asInt64(b []byte) []int64 {
+	n := len(b) / 8
+	if n == 0 {
+		return nil
+	}
+	if littleEndian {
+		return unsafe.Slice((*int64)(unsafe.Pointer(&b[0])), n)
+	}
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(binary.LittleEndian.Uint64(b[i*8:]))
+	}
+	return out
+}
+
+func asInt32(b []byte) []int32 {
+	n := len(b) / 4
+	if n == 0 {
+		return nil
+	}
+	if littleEndian {
+		return unsafe.Slice((*int32)(unsafe.Pointer(&b[0])), n)
+	}
+	out := make([]int32, n)
+	for i := range out {
+		out[i] = int32(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return out
+}
+
+func asFloat32(b []byte) []float32 {
+	n := len(b) / 4
+	if n == 0 {
+		return nil
+	}
+	if littleEndian {
+		return unsafe.Slice((*float32)(unsafe.Pointer(&b[0])), n)
+	}
+	out := make([]float32, n)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[i*4:]))
+	}
+	return out
+}
+
+// readAll es el respaldo sin mmap: lee el archivo completo al heap.
+func readAll(path string) (*mapping, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &mapping{b: b}, nil
+}
+
+// mapping es una región de bytes de solo lectura (mapeada o en heap).
+type mapping struct {
+	b     []byte
+	unmap func() error // nil si b vive en heap
+}
+
+func (mp *mapping) close() error {
+	if mp.unmap == nil {
+		mp.b = nil
+		return nil
+	}
+	err := mp.unmap()
+	mp.b, mp.unmap = nil, nil
+	return err
+}
@@ -0,0 +1,227 @@
+package csr
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// sample es la matriz usuario×ítem de 3×4:
+//
+//	u0: i1=1.5 i3=4
+//	u1: (vacía)
+//	u2: i0=2   i1=3  i2=5
+func sample(t *testing.T) *Matrix {
+	t.Helper()
+	m, err := New(Meta{Items: 4, Axis: AxisUser},
+		[]int64{0, 2, 2, 5},
+		[]int32{1, 3, 0, 1, 2},
+		[]float32{1.5, 4, 2, 3, 5})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return m
+}
+
+func equal(t *testing.T, got, want *Matrix) {
+	t.Helper()
+	if got.Rows() != want.Rows() || got.Cols() != want.Cols() {
+		t.Fatalf("forma %dx%d, se espera %dx%d", got.Rows(), got.Cols(), want.Rows(), want.Cols())
+	}
+	if !slices.Equal(got.Indptr, want.Indptr) {
+		t.Errorf("indptr %v, se espera %v", got.Indptr, want.Indptr)
+	}
+	if !slices.Equal(got.Indices, want.Indices) {
+		t.Errorf("indices %v, se espera %v", got.Indices, want.Indices)
+	}
+	if !slices.Equal(got.Data, want.Data) {
+		t.Errorf("data %v, se espera %v", got.Data, want.Data)
+	}
+	if !slices.Equal(got.TS, want.TS) {
+		t.Errorf("ts %v, se espera %v", got.TS, want.TS)
+	}
+}
+
+func TestWriteOpenRoundTrip(t *testing.T) {
+	for _, withTS := range []bool{false, true} {
+		name := "sin_ts"
+		if withTS {
+			name = "con_ts"
+		}
+		t.Run(name, func(t *testing.T) {
+			m := sample(t)
+			if withTS {
+				if err := m.SetTS([]int64{10, 20, 30, 40, 50}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			dir := t.TempDir()
+			if err := Write(dir, m); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			_, err := os.Stat(filepath.Join(dir, TSFile))
+			if withTS != (err == nil) {
+				t.Fatalf("ts.bin presente=%v, se espera %v", err == nil, withTS)
+			}
+
+			got, err := Open(dir)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			defer got.Close()
+			equal(t, got, m)
+			if got.Meta.Axis != AxisUser || got.Meta.NNZ != 5 {
+				t.Errorf("meta %+v", got.Meta)
+			}
+			if err := got.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+// writeBroken escribe sample en un directorio y deja que edit modifique
+// meta.json antes de devolver el directorio.
+func writeBroken(t *testing.T, edit func(*Meta)) string {
+	t.Helper()
+	m := sample(t)
+	dir := t.TempDir()
+	if err := Write(dir, m); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	meta := m.Meta
+	edit(&meta)
+	putMeta(t, dir, meta)
+	return dir
+}
+
+func putMeta(t *testing.T, dir string, meta Meta) {
+	t.Helper()
+	b, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, MetaFile), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenRejectsBadDTypes(t *testing.T) {
+	cases := map[string]func(*Meta){
+		"indptr":  func(mt *Meta) { mt.DTypes.Indptr = DTypeInt32 },
+		"indices": func(mt *Meta) { mt.DTypes.Indices = DTypeInt64 },
+		"data":    func(mt *Meta) { mt.DTypes.Data = "float64" },
+		"ts":      func(mt *Meta) { mt.DTypes.TS = DTypeInt32 },
+	}
+	for name, edit := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Open(writeBroken(t, edit))
+			if err == nil || !strings.Contains(err.Error(), "dtype de "+name) {
+				t.Fatalf("error %v, se espera dtype de %s no soportado", err, name)
+			}
+		})
+	}
+}
+
+func TestOpenRejectsBadNNZ(t *testing.T) {
+	_, err := Open(writeBroken(t, func(mt *Meta) { mt.NNZ = 4 }))
+	if err == nil || !strings.Contains(err.Error(), "nnz=4") {
+		t.Fatalf("error %v, se espera nnz inconsistente", err)
+	}
+}
+
+func TestOpenRejectsBadIndptr(t *testing.T) {
+	dir := writeBroken(t, func(*Meta) {})
+	b := make([]byte, 8*4)
+	for i, v := range []int64{0, 2, 2, 4} { // termina antes de nnz=5
+		binary.LittleEndian.PutUint64(b[8*i:], uint64(v))
+	}
+	if err := os.WriteFile(filepath.Join(dir, IndptrFile), b, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Open(dir)
+	if err == nil || !strings.Contains(err.Error(), "indptr debe ir de 0 a nnz") {
+		t.Fatalf("error %v, se espera indptr inválido", err)
+	}
+
+	if _, err := New(Meta{Items: 4}, []int64{1, 2}, []int32{0}, []float32{1}); err == nil {
+		t.Fatal("New aceptó un indptr que no empieza en 0")
+	}
+}
+
+func TestOpenValidates(t *testing.T) {
+	cases := map[string]struct {
+		indptr  []int64
+		indices []int32
+		want    string
+	}{
+		"indptr_decreciente": {[]int64{0, 3, 2, 5}, []int32{1, 3, 0, 1, 2}, "indptr decrece en la fila 1"},
+		"columna_fuera":      {[]int64{0, 2, 2, 5}, []int32{1, 4, 0, 1, 2}, "columna 4 fuera de rango"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			// New solo chequea tamaños y extremos, así que sirve para
+			// escribir una matriz rota a disco.
+			m, err := New(Meta{Items: 4}, tc.indptr, tc.indices, []float32{1.5, 4, 2, 3, 5})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			dir := t.TempDir()
+			if err := Write(dir, m); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			_, err = Open(dir)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error %v, se espera %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestTransposeTwice(t *testing.T) {
+	m := sample(t)
+	if err := m.SetTS([]int64{10, 20, 30, 40, 50}); err != nil {
+		t.Fatal(err)
+	}
+	tr := m.Transpose()
+	if tr.Meta.Axis != AxisItem || tr.Rows() != 4 || tr.Cols() != 3 {
+		t.Fatalf("transpuesta axis=%s %dx%d", tr.Meta.Axis, tr.Rows(), tr.Cols())
+	}
+	idx, val := tr.Row(1) // ítem 1: u0=1.5, u2=3
+	if !slices.Equal(idx, []int32{0, 2}) || !slices.Equal(val, []float32{1.5, 3}) {
+		t.Errorf("fila 1 de la transpuesta: %v %v", idx, val)
+	}
+	back := tr.Transpose()
+	if back.Meta.Axis != AxisUser {
+		t.Errorf("axis %s, se espera %s", back.Meta.Axis, AxisUser)
+	}
+	equal(t, back, m)
+}
+
+func TestSliceRows(t *testing.T) {
+	m := sample(t)
+	if err := m.SetTS([]int64{10, 20, 30, 40, 50}); err != nil {
+		t.Fatal(err)
+	}
+	s := m.SliceRows(1, 3)
+	if s.Rows() != 2 || s.Cols() != 4 || s.Meta.NNZ != 3 || s.Meta.Users != 2 {
+		t.Fatalf("vista %dx%d nnz=%d users=%d", s.Rows(), s.Cols(), s.Meta.NNZ, s.Meta.Users)
+	}
+	if !slices.Equal(s.Indptr, []int64{0, 0, 3}) {
+		t.Errorf("indptr %v, se espera rebasado a [0 0 3]", s.Indptr)
+	}
+	idx, val := s.Row(1)
+	if !slices.Equal(idx, []int32{0, 1, 2}) || !slices.Equal(val, []float32{2, 3, 5}) {
+		t.Errorf("fila 1: %v %v", idx, val)
+	}
+	if !slices.Equal(s.TS, []int64{30, 40, 50}) {
+		t.Errorf("ts %v", s.TS)
+	}
+	if s.SliceRows(0, 0).Rows() != 0 {
+		t.Error("SliceRows vacío con filas")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !unix && !windows
+
+package csr
+
+// Sin mmap disponible: se lee el archivo completo.
+func mapReadOnly(path string) (*mapping, error) { return readAll(path) }
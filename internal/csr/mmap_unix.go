@@ -0,0 +1,29 @@
+//go:build unix
+
+package csr
+
+import (
+	"os"
+	"syscall"
+)
+
+func mapReadOnly(path string) (*mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := st.Size()
+	if size == 0 {
+		return &mapping{}, nil
+	}
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	return &mapping{b: b, unmap: func() error { return syscall.Munmap(b) }}, nil
+}
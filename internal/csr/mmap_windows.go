@@ -0,0 +1,40 @@
+//go:build windows
+
+package csr
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+func mapReadOnly(path string) (*mapping, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := st.Size()
+	if size == 0 {
+		return &mapping{}, nil
+	}
+	h, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY,
+		uint32(size>>32), uint32(size), nil)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	// la vista mantiene viva la sección; el handle ya no hace falta
+	defer syscall.CloseHandle(h)
+	addr, err := syscall.MapViewOfFile(h, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		return nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	// addr es memoria fuera del heap de Go: convertir sin pasar por uintptr→Pointer
+	ptr := *(*unsafe.Pointer)(unsafe.Pointer(&addr))
+	b := unsafe.Slice((*byte)(ptr), int(size))
+	return &mapping{b: b, unmap: func() error { return syscall.UnmapViewOfFile(addr) }}, nil
+}
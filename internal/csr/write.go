@@ -0,0 +1,211 @@
+package csr
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+// Write guarda m en dir con el layout que lee Open (los .bin en
+// little-endian y meta.json con dtypes y eje). ts.bin solo se escribe si m
+// trae timestamps.
+func Write(dir string, m *Matrix) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	if err := writeBin(filepath.Join(dir, IndptrFile), len(m.Indptr), 8, func(b []byte, i int) {
+		binary.LittleEndian.PutUint64(b, uint64(m.Indptr[i]))
+	}); err != nil {
+		return err
+	}
+	if err := writeBin(filepath.Join(dir, IndicesFile), len(m.Indices), 4, func(b []byte, i int) {
+		binary.LittleEndian.PutUint32(b, uint32(m.Indices[i]))
+	}); err != nil {
+		return err
+	}
+	if err := writeBin(filepath.Join(dir, DataFile), len(m.Data), 4, func(b []byte, i int) {
+		binary.LittleEndian.PutUint32(b, math.Float32bits(m.Data[i]))
+	}); err != nil {
+		return err
+	}
+	tsPath := filepath.Join(dir, TSFile)
+	if m.TS != nil {
+		if err := writeBin(tsPath, len(m.TS), 8, func(b []byte, i int) {
+			binary.LittleEndian.PutUint64(b, uint64(m.TS[i]))
+		}); err != nil {
+			return err
+		}
+	} else if err := os.Remove(tsPath); err != nil && !os.IsNotExist(err) {
+		return err // un ts.bin viejo no debe quedar junto a datos nuevos
+	}
+	return writeMeta(dir, m.Meta)
+}
+
+func writeMeta(dir string, meta Meta) error {
+	jb, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MetaFile), jb, 0o644)
+}
+
+// writeBin escribe n valores de width bytes; put codifica el i-ésimo.
+func writeBin(path string, n, width int, put func(b []byte, i int)) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	buf := make([]byte, width)
+	for i := 0; i < n; i++ {
+		put(buf, i)
+		if _, err := w.Write(buf); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// StreamWriter escribe un CSR valor a valor sin armarlo en memoria: solo
+// indptr (O(filas)) vive en heap; indices, data y ts van directo a disco.
+// Los valores deben llegar ordenados por fila.
+type StreamWriter struct {
+	dir     string
+	meta    Meta
+	indptr  []int64 // conteos por fila hasta Close
+	last    int
+	indices *binWriter
+	data    *binWriter
+	ts      *binWriter // nil sin timestamps
+}
+
+// NewStreamWriter crea los archivos de dir. meta.Axis indica qué son las
+// filas (y por tanto cuántas hay: Users o Items).
+func NewStreamWriter(dir string, meta Meta, withTS bool) (*StreamWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	rows := meta.Users
+	if meta.Axis == AxisItem {
+		rows = meta.Items
+	} else {
+		meta.Axis = AxisUser
+	}
+	w := &StreamWriter{dir: dir, meta: meta, indptr: make([]int64, rows+1)}
+	var err error
+	if w.indices, err = createBin(filepath.Join(dir, IndicesFile)); err != nil {
+		return nil, err
+	}
+	if w.data, err = createBin(filepath.Join(dir, DataFile)); err != nil {
+		w.abort()
+		return nil, err
+	}
+	tsPath := filepath.Join(dir, TSFile)
+	if withTS {
+		if w.ts, err = createBin(tsPath); err != nil {
+			w.abort()
+			return nil, err
+		}
+	} else if err := os.Remove(tsPath); err != nil && !os.IsNotExist(err) {
+		w.abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Append agrega el valor (row, col); ts se ignora si no hay timestamps.
+func (w *StreamWriter) Append(row int, col int32, val float32, ts int64) error {
+	if row < w.last || row >= len(w.indptr)-1 {
+		return fmt.Errorf("csr: fila %d fuera de orden o de rango (última %d, filas %d)", row, w.last, len(w.indptr)-1)
+	}
+	w.last = row
+	w.indptr[row+1]++
+	if err := w.indices.put32(uint32(col)); err != nil {
+		return err
+	}
+	if err := w.data.put32(math.Float32bits(val)); err != nil {
+		return err
+	}
+	if w.ts != nil {
+		return w.ts.put64(uint64(ts))
+	}
+	return nil
+}
+
+// Close cierra los .bin y escribe indptr.bin y meta.json.
+func (w *StreamWriter) Close() error {
+	err := errors.Join(w.indices.close(), w.data.close())
+	if w.ts != nil {
+		err = errors.Join(err, w.ts.close())
+	}
+	if err != nil {
+		return err
+	}
+	for r := 1; r < len(w.indptr); r++ {
+		w.indptr[r] += w.indptr[r-1]
+	}
+	if err := writeBin(filepath.Join(w.dir, IndptrFile), len(w.indptr), 8, func(b []byte, i int) {
+		binary.LittleEndian.PutUint64(b, uint64(w.indptr[i]))
+	}); err != nil {
+		return err
+	}
+	w.meta.NNZ = int(w.indptr[len(w.indptr)-1])
+	w.meta.DTypes = DTypes{Indptr: DTypeInt64, Indices: DTypeInt32, Data: DTypeFloat32}
+	if w.ts != nil {
+		w.meta.DTypes.TS = DTypeInt64
+	}
+	return writeMeta(w.dir, w.meta)
+}
+
+func (w *StreamWriter) abort() {
+	for _, bw := range []*binWriter{w.indices, w.data, w.ts} {
+		if bw != nil {
+			_ = bw.close()
+		}
+	}
+}
+
+// binWriter es un archivo .bin con buffer que recibe valores de a uno.
+type binWriter struct {
+	f   *os.File
+	w   *bufio.Writer
+	buf [8]byte
+}
+
+func createBin(path string) (*binWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &binWriter{f: f, w: bufio.NewWriterSize(f, 1<<20)}, nil
+}
+
+func (bw *binWriter) put32(v uint32) error {
+	binary.LittleEndian.PutUint32(bw.buf[:4], v)
+	_, err := bw.w.Write(bw.buf[:4])
+	return err
+}
+
+func (bw *binWriter) put64(v uint64) error {
+	binary.LittleEndian.PutUint64(bw.buf[:], v)
+	_, err := bw.w.Write(bw.buf[:])
+	return err
+}
+
+func (bw *binWriter) close() error {
+	if err := bw.w.Flush(); err != nil {
+		bw.f.Close()
+		return err
+	}
+	return bw.f.Close()
+}
@@ -0,0 +1,160 @@
+package experiment
+
+/*
+EXPERIMENTOS declarativos (JSON)
+
+Un archivo describe dataset, métrica, modo, hiperparámetros y evaluación:
+
+  {
+    "name": "cosine_conc_u50",
+    "dataset": {"data": "data", "artifacts": "artifacts"},
+    "sim": {"metric": "cosine", "mode": "item", "concurrent": true,
+            "k": 20, "min_co": 3, "pct_users": 50, "pct_items": 100,
+            "shrink": 20, "positive": true, "workers": 20},
+    "recommend": {"test_ratio": 0.1, "k_eval": 20, "k_metrics": 20, "rel_th": 4.0}
+  }
+
+- Las claves de "sim" y "recommend" son los nombres de los flags de cada
+  comando; los flags pasados en la línea de comandos tienen prioridad.
+- Con "name", los reportes van a <artifacts>/reports/<name>/ y los Top-K de
+  sim a <artifacts>/sim/<name>/ (recommend busca ahí un --sim sin
+  directorio antes que en sim/).
+- Cada corrida guarda la configuración resuelta (todos los flags finales)
+  junto a su reporte: <reporte>.config.json.
+*/
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dataset son las raíces de datos y artifacts del experimento.
+type Dataset struct {
+	Data      string `json:"data,omitempty"`
+	Artifacts string `json:"artifacts,omitempty"`
+}
+
+// Config es el contenido de un archivo de experimento.
+type Config struct {
+	Name      string         `json:"name,omitempty"`
+	Dataset   Dataset        `json:"dataset"`
+	Sim       map[string]any `json:"sim,omitempty"`
+	Recommend map[string]any `json:"recommend,omitempty"`
+
+	path string
+}
+
+// Load lee y valida un archivo de experimento.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	dec.DisallowUnknownFields()
+	c := &Config{path: path}
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if strings.ContainsAny(c.Name, `/\`) || c.Name == "." || c.Name == ".." {
+		return nil, fmt.Errorf("%s: name %q no es un nombre de directorio válido (sin separadores, ni . ni ..)", path, c.Name)
+	}
+	return c, nil
+}
+
+// Path es el archivo del que se leyó la configuración.
+func (c *Config) Path() string { return c.path }
+
+// Section devuelve los valores de una etapa (sim | recommend); nil si c es
+// nil o la etapa no tiene sección.
+func (c *Config) Section(stage string) map[string]any {
+	if c == nil {
+		return nil
+	}
+	switch stage {
+	case "sim":
+		return c.Sim
+	case "recommend":
+		return c.Recommend
+	}
+	return nil
+}
+
+// Apply asigna values a los flags de fs, salvo los que ya se pasaron
+// explícitamente en la línea de comandos.
+func Apply(fs *flag.FlagSet, values map[string]any, explicit map[string]bool) error {
+	for name, v := range values {
+		if fs.Lookup(name) == nil {
+			return fmt.Errorf("flag desconocido %q en la sección", name)
+		}
+		if explicit[name] {
+			continue
+		}
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case bool:
+			s = strconv.FormatBool(x)
+		case float64:
+			s = strconv.FormatFloat(x, 'f', -1, 64)
+		default:
+			return fmt.Errorf("valor no soportado para %q: %v", name, v)
+		}
+		if err := fs.Set(name, s); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Resolved es la configuración efectiva de una corrida.
+type Resolved struct {
+	Experiment string         `json:"experiment,omitempty"`
+	Config     string         `json:"config,omitempty"` // archivo de origen
+	Stage      string         `json:"stage"`
+	Data       string         `json:"data"`
+	Artifacts  string         `json:"artifacts"`
+	Flags      map[string]any `json:"flags"`
+	Time       time.Time      `json:"resolved_at"`
+}
+
+// Resolve toma el valor final de todos los flags de fs.
+func Resolve(fs *flag.FlagSet) map[string]any {
+	out := map[string]any{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == "data" || f.Name == "artifacts" || f.Name == "config" {
+			return
+		}
+		if g, ok := f.Value.(flag.Getter); ok {
+			out[f.Name] = g.Get()
+		} else {
+			out[f.Name] = f.Value.String()
+		}
+	})
+	return out
+}
+
+// ConfigPath es el archivo de configuración resuelta de un reporte.
+func ConfigPath(report string) string {
+	return strings.TrimSuffix(report, filepath.Ext(report)) + ".config.json"
+}
+
+// Write guarda r junto al reporte.
+func Write(report string, r Resolved) error {
+	if err := os.MkdirAll(filepath.Dir(report), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ConfigPath(report), b, 0o644)
+}
@@ -0,0 +1,298 @@
+package importers
+
+/*
+Formatos registrados.
+
+  ml100k, ml1m, ml10m: separador fijo, sin cabecera, columnas en orden
+    user, item, rating, timestamp (--columns no aplica).
+  csv, tsv: encoding/csv; por defecto con cabecera y nombres de MovieLens
+    (userId, movieId, rating, timestamp). Con --header=false las columnas
+    son posiciones (por defecto 0,1,2,3).
+  jsonl: por defecto los campos user_id, item_id, rating y timestamp; los
+    ids pueden venir como string o número y el timestamp como entero (Unix)
+    o texto RFC 3339 / "2006-01-02".
+*/
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register(fixed{name: "ml100k", file: "u.data", sep: "\t"})
+	Register(fixed{name: "ml1m", file: "ratings.dat", sep: "::"})
+	Register(fixed{name: "ml10m", file: "ratings.dat", sep: "::"})
+	Register(delimited{name: "csv", comma: ','})
+	Register(delimited{name: "tsv", comma: '\t'})
+	Register(jsonLines{})
+}
+
+// roles en el orden de las columnas por defecto.
+var roles = []string{"user", "item", "rating", "timestamp"}
+
+// parseColumns interpreta --columns sobre los valores por defecto def.
+func parseColumns(spec string, def map[string]string) (map[string]string, error) {
+	cols := map[string]string{}
+	for k, v := range def {
+		cols[k] = v
+	}
+	if strings.TrimSpace(spec) == "" {
+		return cols, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		role, col, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || col == "" {
+			return nil, fmt.Errorf("--columns: se espera rol=columna (recibido %q)", part)
+		}
+		switch role {
+		case "user", "item", "rating", "timestamp":
+			cols[role] = col
+		default:
+			return nil, fmt.Errorf("--columns: rol %q desconocido (user, item, rating, timestamp)", role)
+		}
+	}
+	return cols, nil
+}
+
+// record arma un Record desde los valores de texto de cada rol; ok=false si
+// falta user/item o el rating (o el timestamp, si viene) no parsea.
+func record(user, item, rating, ts string) (Record, bool) {
+	r := Record{User: strings.TrimSpace(user), Item: strings.TrimSpace(item)}
+	if r.User == "" || r.Item == "" {
+		return r, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(rating), 64)
+	if err != nil {
+		return r, false
+	}
+	r.Rating = v
+	if ts = strings.TrimSpace(ts); ts != "" {
+		t, ok := parseTS(ts)
+		if !ok {
+			return r, false
+		}
+		r.TS = t
+	}
+	return r, true
+}
+
+func parseTS(s string) (int64, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, true
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return int64(f), true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Unix(), true
+		}
+	}
+	return 0, false
+}
+
+// ---- MovieLens 100K / 1M / 10M ----
+
+type fixed struct {
+	name, file, sep string
+}
+
+func (f fixed) Name() string { return f.name }
+func (f fixed) File() string { return f.file }
+
+func (f fixed) Each(path string, opt Options, fn func(Record) error) (int64, error) {
+	if opt.Columns != "" {
+		return 0, fmt.Errorf("--columns no aplica a --format=%s (columnas fijas)", f.name)
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	var skipped int64
+	sc := bufio.NewScanner(bufio.NewReader(in))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, f.sep)
+		if len(parts) < 3 {
+			skipped++
+			continue
+		}
+		ts := ""
+		if len(parts) > 3 {
+			ts = parts[3]
+		}
+		r, ok := record(parts[0], parts[1], parts[2], ts)
+		if !ok {
+			skipped++
+			continue
+		}
+		if err := fn(r); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, sc.Err()
+}
+
+// ---- CSV / TSV genérico ----
+
+type delimited struct {
+	name  string
+	comma rune
+}
+
+func (d delimited) Name() string { return d.name }
+func (d delimited) File() string { return "" }
+
+func (d delimited) Each(path string, opt Options, fn func(Record) error) (int64, error) {
+	def := map[string]string{"user": "0", "item": "1", "rating": "2", "timestamp": "3"}
+	if opt.Header {
+		def = map[string]string{"user": "userId", "item": "movieId", "rating": "rating", "timestamp": "timestamp"}
+	}
+	cols, err := parseColumns(opt.Columns, def)
+	if err != nil {
+		return 0, err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	rd := csv.NewReader(bufio.NewReader(in))
+	rd.Comma = d.comma
+	rd.FieldsPerRecord = -1
+	rd.LazyQuotes = true
+	rd.ReuseRecord = true
+
+	// posición de cada rol (-1 = timestamp ausente)
+	pos := map[string]int{}
+	if opt.Header {
+		header, err := rd.Read()
+		if err != nil {
+			return 0, fmt.Errorf("leer cabecera: %w", err)
+		}
+		for _, role := range roles {
+			pos[role] = -1
+			for k, name := range header {
+				if strings.TrimSpace(name) == cols[role] {
+					pos[role] = k
+				}
+			}
+			// timestamp es opcional salvo que --columns lo nombre
+			if pos[role] < 0 && (role != "timestamp" || cols[role] != def[role]) {
+				return 0, fmt.Errorf("no hay columna %q para %s en la cabecera %v (ver --columns)", cols[role], role, header)
+			}
+		}
+	} else {
+		for _, role := range roles {
+			k, err := strconv.Atoi(cols[role])
+			if err != nil || k < 0 {
+				return 0, fmt.Errorf("--columns: sin cabecera %s debe ser una posición (recibido %q)", role, cols[role])
+			}
+			pos[role] = k
+		}
+	}
+
+	var skipped int64
+	for {
+		row, err := rd.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			skipped++
+			continue
+		}
+		get := func(role string) (string, bool) {
+			k := pos[role]
+			if k < 0 || k >= len(row) {
+				return "", role == "timestamp"
+			}
+			return row[k], true
+		}
+		u, ok1 := get("user")
+		i, ok2 := get("item")
+		v, ok3 := get("rating")
+		ts, ok4 := get("timestamp")
+		r, ok := record(u, i, v, ts)
+		if !ok || !ok1 || !ok2 || !ok3 || !ok4 {
+			skipped++
+			continue
+		}
+		if err := fn(r); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, nil
+}
+
+// ---- JSON lines ----
+
+type jsonLines struct{}
+
+func (jsonLines) Name() string { return "jsonl" }
+func (jsonLines) File() string { return "" }
+
+func (jsonLines) Each(path string, opt Options, fn func(Record) error) (int64, error) {
+	cols, err := parseColumns(opt.Columns, map[string]string{
+		"user": "user_id", "item": "item_id", "rating": "rating", "timestamp": "timestamp",
+	})
+	if err != nil {
+		return 0, err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	var skipped int64
+	sc := bufio.NewScanner(bufio.NewReader(in))
+	sc.Buffer(make([]byte, 64<<10), 16<<20) // reseñas largas
+	for sc.Scan() {
+		line := bytes.TrimSpace(sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var obj map[string]any
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.UseNumber()
+		if err := dec.Decode(&obj); err != nil {
+			skipped++
+			continue
+		}
+		r, ok := record(jsonField(obj, cols["user"]), jsonField(obj, cols["item"]),
+			jsonField(obj, cols["rating"]), jsonField(obj, cols["timestamp"]))
+		if !ok {
+			skipped++
+			continue
+		}
+		if err := fn(r); err != nil {
+			return skipped, err
+		}
+	}
+	return skipped, sc.Err()
+}
+
+// jsonField devuelve el campo como texto ("" si falta o no es escalar).
+func jsonField(obj map[string]any, key string) string {
+	switch v := obj[key].(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+package importers
+
+/*
+IMPORTADORES de ratings de otros formatos → ratings.csv canónico
+
+clean lee el formato de MovieLens 25M (ratings.csv con cabecera
+userId,movieId,rating,timestamp). Con --format distinto de movielens, clean
+primero convierte el archivo de origen con el importador registrado y sigue
+igual sobre el resultado, así remap recibe siempre el mismo ratings_min5.csv:
+
+  ml100k   <data>/u.data       user \t item \t rating \t timestamp, sin cabecera
+  ml1m     <data>/ratings.dat  UserID::MovieID::Rating::Timestamp
+  ml10m    <data>/ratings.dat  (mismo formato, ratings en medias estrellas)
+  csv      --source            columnas por nombre (con cabecera) o posición
+  tsv      --source            ídem con tabulador
+  jsonl    --source            un objeto JSON por línea, campos por nombre
+
+--columns mapea los roles user, item, rating y timestamp (opcional) a
+columnas: "user=reviewerID,item=asin,rating=overall,timestamp=unixReviewTime"
+o, sin cabecera, posiciones "user=0,item=1,rating=2". Sin --columns cada
+importador usa sus nombres por defecto (ver formats.go).
+
+Ids: si todos los ids de un rol son enteros se conservan (los de MovieLens
+siguen uniendo con movies.csv); si no, se numeran 1, 2, … por orden de
+aparición y la correspondencia queda en <artifacts>/import/{user,item}_ids.csv.
+Por eso Convert recorre el origen dos veces.
+
+Un formato nuevo solo implementa Importer y se registra en init.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Record es una fila de origen con los ids tal como vienen.
+type Record struct {
+	User, Item string
+	Rating     float64
+	TS         int64 // 0 si el origen no trae timestamp
+}
+
+// Options son los flags comunes a los importadores.
+type Options struct {
+	Columns string `json:"columns,omitempty"` // role=columna,… (vacío = por defecto del formato)
+	Header  bool   `json:"header"`            // csv/tsv: la primera fila nombra las columnas
+}
+
+// Importer lee un formato de ratings.
+type Importer interface {
+	// Name es el valor de --format.
+	Name() string
+	// File es el nombre del archivo dentro de --data ("" = requiere --source).
+	File() string
+	// Each llama a fn por cada fila válida de path y devuelve cuántas filas
+	// no se pudieron interpretar.
+	Each(path string, opt Options, fn func(Record) error) (skipped int64, err error)
+}
+
+var registry = map[string]Importer{}
+
+// Register agrega un importador al registro por nombre.
+func Register(imp Importer) {
+	registry[imp.Name()] = imp
+}
+
+// ByName devuelve el importador registrado con ese nombre.
+func ByName(name string) (Importer, error) {
+	imp, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("formato desconocido %q (disponibles: %s)", name, strings.Join(Names(), ", "))
+	}
+	return imp, nil
+}
+
+// Names lista los formatos registrados en orden alfabético.
+func Names() []string {
+	out := make([]string, 0, len(registry))
+	for n := range registry {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Stats resume una conversión.
+type Stats struct {
+	Rows        int64 `json:"rows"`         // filas escritas
+	Skipped     int64 `json:"skipped"`      // filas de origen descartadas por no parsear
+	Users       int   `json:"users"`        // ids de usuario distintos
+	Items       int   `json:"items"`        // ids de ítem distintos
+	MappedUsers bool  `json:"mapped_users"` // ids de usuario renumerados (ver user_ids.csv)
+	MappedItems bool  `json:"mapped_items"` // ids de ítem renumerados (ver item_ids.csv)
+}
+
+// Convert escribe src como ratings.csv canónico en dst. Si hay que
+// renumerar ids, las correspondencias van a idsDir/{user,item}_ids.csv.
+func Convert(imp Importer, src, dst, idsDir string, opt Options) (Stats, error) {
+	var st Stats
+
+	// 1ª pasada: ¿todos los ids son enteros?
+	numU, numI := true, true
+	skipped, err := imp.Each(src, opt, func(r Record) error {
+		if numU && !isInt(r.User) {
+			numU = false
+		}
+		if numI && !isInt(r.Item) {
+			numI = false
+		}
+		return nil
+	})
+	if err != nil {
+		return st, fmt.Errorf("%s: %w", src, err)
+	}
+	st.Skipped = skipped
+	st.MappedUsers, st.MappedItems = !numU, !numI
+
+	// 2ª pasada: escribir con ids enteros
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return st, err
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return st, err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{"userId", "movieId", "rating", "timestamp"})
+
+	users, items := newIDMap(numU), newIDMap(numI)
+	if _, err := imp.Each(src, opt, func(r Record) error {
+		st.Rows++
+		return w.Write([]string{
+			users.id(r.User), items.id(r.Item),
+			strconv.FormatFloat(r.Rating, 'f', -1, 64), strconv.FormatInt(r.TS, 10),
+		})
+	}); err != nil {
+		return st, fmt.Errorf("%s: %w", src, err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return st, err
+	}
+	st.Users, st.Items = users.len(), items.len()
+
+	if st.MappedUsers {
+		if err := users.write(filepath.Join(idsDir, "user_ids.csv"), "userId"); err != nil {
+			return st, err
+		}
+	}
+	if st.MappedItems {
+		if err := items.write(filepath.Join(idsDir, "item_ids.csv"), "movieId"); err != nil {
+			return st, err
+		}
+	}
+	return st, nil
+}
+
+func isInt(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
+
+// idMap numera ids de origen (o los conserva si son enteros, solo
+// contándolos).
+type idMap struct {
+	keep  bool
+	ids   map[string]int
+	order []string
+}
+
+func newIDMap(keep bool) *idMap { return &idMap{keep: keep, ids: map[string]int{}} }
+
+func (m *idMap) id(src string) string {
+	n, ok := m.ids[src]
+	if !ok {
+		n = len(m.ids) + 1
+		m.ids[src] = n
+		if !m.keep {
+			m.order = append(m.order, src)
+		}
+	}
+	if m.keep {
+		return src
+	}
+	return strconv.Itoa(n)
+}
+
+func (m *idMap) len() int { return len(m.ids) }
+
+// write guarda <col>,source para cada id numerado.
+func (m *idMap) write(path, col string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{col, "source"})
+	for k, src := range m.order {
+		_ = w.Write([]string{strconv.Itoa(k + 1), src})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,219 @@
+package layout
+
+/*
+Rutas del pipeline.
+
+Todas las etapas leen y escriben bajo dos raíces: el directorio de datos
+crudos de MovieLens (--data) y la raíz de artifacts (--artifacts). Los
+nombres de archivo dentro de cada raíz son fijos y viven solo aquí.
+
+  <data>/ratings.csv, <data>/movies.csv (opcionales: tags.csv, genome-*.csv)
+  <artifacts>/clean_report.txt, ratings_min5.csv, clean_filter_report.txt
+  <artifacts>/ratings_imported.csv, import/{user,item}_ids.csv  (clean --format≠movielens)
+  <artifacts>/ratings_ui.{csv,bin}, remap_report.txt, index/{user_map,item_map}.csv
+  <artifacts>/index/items.csv, movies_report.txt
+  <artifacts>/features/{genome,tags}_csr/, {genome,tag}_vocab.csv, features_report.txt
+  <artifacts>/{user,item}_means.csv, matrix_{user,item}_csr/, normalize_<axis>_report.txt
+  <artifacts>/{user,item}_stds.csv (--norm=zscore), {user,item}_ranks.csv (--norm=percentile)
+  <artifacts>/matrix_raw_csr/, matrix_raw_csc/  (normalize --raw: ratings sin centrar)
+  <artifacts>/baseline.json, {user,item}_bias.csv, matrix_baseline_csr/
+  <artifacts>/sim/<mode>_topk_<metric>[_conc].csv
+  <artifacts>/reports/[<experimento>/]
+  (cada *_report.txt y recommend_*.txt lleva al lado su .json, ver internal/report)
+  <artifacts>/tmp/   (runs del ordenamiento externo; se borran al terminar)
+*/
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Layout resuelve las rutas de entrada/salida a partir de las dos raíces.
+type Layout struct {
+	Data       string // entradas crudas (ratings.csv, movies.csv)
+	Artifacts  string // todo lo que generan las etapas
+	Experiment string // si no es vacío, los reportes van a reports/<Experiment>/ y los Top-K a sim/<Experiment>/
+}
+
+// Default es el layout histórico: ./data y ./artifacts.
+func Default() Layout {
+	return Layout{Data: "data", Artifacts: "artifacts"}
+}
+
+func (l Layout) data(name string) string { return filepath.Join(l.Data, name) }
+
+func (l Layout) art(parts ...string) string {
+	return filepath.Join(append([]string{l.Artifacts}, parts...)...)
+}
+
+// Rel devuelve path relativo a la raíz de artifacts, con "/" como
+// separador (path tal cual si está fuera de ella). Es la clave de las
+// etapas en el manifest: única aunque dos experimentos usen el mismo nombre
+// de archivo.
+func (l Layout) Rel(path string) string {
+	rel, err := filepath.Rel(l.Artifacts, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ---- entradas ----
+
+func (l Layout) Ratings() string      { return l.data("ratings.csv") }
+func (l Layout) Movies() string       { return l.data("movies.csv") }
+func (l Layout) Tags() string         { return l.data("tags.csv") }
+func (l Layout) GenomeTags() string   { return l.data("genome-tags.csv") }
+func (l Layout) GenomeScores() string { return l.data("genome-scores.csv") }
+
+// DataFile es un archivo de origen dentro de --data (p. ej. u.data de ML-100K).
+func (l Layout) DataFile(name string) string { return l.data(name) }
+
+// ---- clean ----
+
+func (l Layout) CleanReport() string  { return l.art("clean_report.txt") }
+func (l Layout) Filtered() string     { return l.art("ratings_min5.csv") }
+func (l Layout) FilterReport() string { return l.art("clean_filter_report.txt") }
+
+// Suspicious es la lista de perfiles sospechosos de clean.
+func (l Layout) Suspicious() string { return l.art("suspicious_users.csv") }
+
+// Imported es el ratings.csv canónico que escribe clean al importar otro
+// formato; ImportDir guarda las correspondencias de ids renumerados.
+func (l Layout) Imported() string  { return l.art("ratings_imported.csv") }
+func (l Layout) ImportDir() string { return l.art("import") }
+
+// ---- remap ----
+
+func (l Layout) Triplets() string    { return l.art("ratings_ui.csv") }
+func (l Layout) TripletsBin() string { return l.art("ratings_ui.bin") } // columnar, ver internal/ratings
+func (l Layout) IndexDir() string    { return l.art("index") }
+func (l Layout) UserMap() string     { return l.art("index", "user_map.csv") }
+func (l Layout) ItemMap() string     { return l.art("index", "item_map.csv") }
+func (l Layout) RemapReport() string { return l.art("remap_report.txt") }
+
+// ---- movies ----
+
+// Items es el catálogo iIdx → título/año/géneros (ver internal/catalog).
+func (l Layout) Items() string        { return l.art("index", "items.csv") }
+func (l Layout) MoviesReport() string { return l.art("movies_report.txt") }
+
+// ---- features (tags) ----
+
+// GenomeCSR y TagsCSR son CSR ítem×feature (ver preprocess/features.go).
+func (l Layout) GenomeCSR() string      { return l.art("features", "genome_csr") }
+func (l Layout) GenomeVocab() string    { return l.art("features", "genome_vocab.csv") }
+func (l Layout) TagsCSR() string        { return l.art("features", "tags_csr") }
+func (l Layout) TagVocab() string       { return l.art("features", "tag_vocab.csv") }
+func (l Layout) FeaturesReport() string { return l.art("features_report.txt") }
+
+// ---- normalize ----
+
+// Means devuelve <axis>_means.csv (axis = user | item).
+func (l Layout) Means(axis string) string { return l.art(axis + "_means.csv") }
+
+// Stds devuelve <axis>_stds.csv (normalize --norm=zscore).
+func (l Layout) Stds(axis string) string { return l.art(axis + "_stds.csv") }
+
+// Ranks devuelve <axis>_ranks.csv (normalize --norm=percentile).
+func (l Layout) Ranks(axis string) string { return l.art(axis + "_ranks.csv") }
+
+// CSRDir devuelve matrix_<axis>_csr (axis = user | item | baseline).
+func (l Layout) CSRDir(axis string) string { return l.art("matrix_" + axis + "_csr") }
+
+// RawDir devuelve el CSR de ratings sin centrar de normalize --raw:
+// matrix_raw_csr (axis = user, filas = usuarios) o matrix_raw_csc (axis =
+// item, filas = ítems).
+func (l Layout) RawDir(axis string) string {
+	if axis == "item" {
+		return l.art("matrix_raw_csc")
+	}
+	return l.art("matrix_raw_csr")
+}
+
+// ImplicitDir devuelve la matriz de pesos implícitos de normalize
+// --axis=implicit: matrix_implicit_csr (axis = user) o matrix_implicit_csc
+// (axis = item).
+func (l Layout) ImplicitDir(axis string) string {
+	if axis == "item" {
+		return l.art("matrix_implicit_csc")
+	}
+	return l.art("matrix_implicit_csr")
+}
+
+// Implicit es implicit.json (normalize --axis=implicit).
+func (l Layout) Implicit() string { return l.art("implicit.json") }
+
+// Baseline es baseline.json (normalize --axis=baseline).
+func (l Layout) Baseline() string { return l.art("baseline.json") }
+
+// Bias devuelve <axis>_bias.csv (axis = user | item).
+func (l Layout) Bias(axis string) string { return l.art(axis + "_bias.csv") }
+
+// NormalizeReport es el reporte de normalize para --axis=user|item|both|baseline|implicit.
+func (l Layout) NormalizeReport(axis string) string {
+	return l.art("normalize_" + axis + "_report.txt")
+}
+
+// ---- similitudes ----
+
+func (l Layout) SimDir() string { return l.art("sim") }
+
+// SimTopK devuelve sim/<mode>_topk_<metric>[_conc].csv (o sim/<Experiment>/…
+// si hay experimento: dos experimentos con la misma métrica y modo pero
+// otros flags no se pisan los vecinos).
+func (l Layout) SimTopK(mode, metric string, concurrent bool) string {
+	return l.art("sim", l.Experiment, fmt.Sprintf("%s_topk_%s%s.csv", mode, metric, concSuffix(concurrent)))
+}
+
+// SimReport devuelve sim/<mode>_<metric>[_conc]_report.txt (o el mismo
+// nombre bajo reports/<Experiment>/ si hay experimento).
+func (l Layout) SimReport(mode, metric string, concurrent bool) string {
+	name := fmt.Sprintf("%s_%s%s_report.txt", mode, metric, concSuffix(concurrent))
+	if l.Experiment != "" {
+		return filepath.Join(l.ReportsDir(), name)
+	}
+	return l.art("sim", name)
+}
+
+// SimFile resuelve un --sim: un nombre sin directorio se busca en
+// sim/<Experiment>/ si hay experimento, y si no en sim/. Si todavía no
+// existe en ninguno de los dos (el pipeline arma recommend antes de correr
+// sim) se devuelve el del experimento, que es donde lo escribe SimTopK.
+func (l Layout) SimFile(name string) string {
+	if filepath.Base(name) != name {
+		return name
+	}
+	if l.Experiment == "" {
+		return l.art("sim", name)
+	}
+	p := l.art("sim", l.Experiment, name)
+	if shared := l.art("sim", name); !exists(p) && exists(shared) {
+		return shared
+	}
+	return p
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func concSuffix(concurrent bool) string {
+	if concurrent {
+		return "_conc"
+	}
+	return ""
+}
+
+// ---- reportes ----
+
+func (l Layout) ReportsDir() string { return l.art("reports", l.Experiment) }
+
+// TmpDir guarda los runs temporales del ordenamiento externo (--mem_limit).
+func (l Layout) TmpDir() string { return l.art("tmp") }
+
+// Manifest es el registro de etapas del pipeline (ver internal/pipeline).
+func (l Layout) Manifest() string { return l.art("manifest.json") }
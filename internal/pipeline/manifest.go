@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// FileSig identifica el contenido de un archivo (o directorio) de la etapa.
+type FileSig struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"` // UnixNano
+	SHA256  string `json:"sha256"`
+}
+
+// Record es lo que se guarda de la última corrida de una etapa.
+type Record struct {
+	Stage    string             `json:"stage"`
+	Params   map[string]string  `json:"params"`
+	Inputs   map[string]FileSig `json:"inputs"`
+	Outputs  map[string]FileSig `json:"outputs"`
+	Finished time.Time          `json:"finished"`
+	Duration string             `json:"duration"`
+}
+
+// Manifest es <artifacts>/manifest.json: un Record por clave de etapa.
+type Manifest struct {
+	path   string
+	Stages map[string]*Record `json:"stages"`
+}
+
+// LoadManifest lee el manifest; si no existe devuelve uno vacío.
+func LoadManifest(path string) (*Manifest, error) {
+	m := &Manifest{path: path, Stages: map[string]*Record{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	if m.Stages == nil {
+		m.Stages = map[string]*Record{}
+	}
+	return m, nil
+}
+
+// Save escribe el manifest de forma atómica (tmp + rename).
+func (m *Manifest) Save() error {
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
+
+// known busca una firma ya calculada de path en cualquier registro.
+func (m *Manifest) known(path string) *FileSig {
+	for _, r := range m.Stages {
+		if s, ok := r.Outputs[path]; ok {
+			return &s
+		}
+		if s, ok := r.Inputs[path]; ok {
+			return &s
+		}
+	}
+	return nil
+}
+
+// sig calcula la firma de path. Si tamaño y mtime coinciden con una firma
+// conocida se reutiliza su sha256 (evita rehashear ratings.csv completo).
+func (m *Manifest) sig(path string) (FileSig, error) {
+	size, mtime, files, err := stat(path)
+	if err != nil {
+		return FileSig{}, err
+	}
+	if k := m.known(path); k != nil && k.Size == size && k.ModTime == mtime {
+		return *k, nil
+	}
+	h := sha256.New()
+	for _, f := range files {
+		if len(files) > 1 || f != path {
+			rel, _ := filepath.Rel(path, f)
+			io.WriteString(h, filepath.ToSlash(rel)+"\x00")
+		}
+		if err := hashFile(h, f); err != nil {
+			return FileSig{}, err
+		}
+	}
+	return FileSig{Size: size, ModTime: mtime, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// stat suma tamaños y toma el mtime máximo; un directorio se recorre
+// completo (en orden) para cubrir, por ejemplo, los CSR.
+func stat(path string) (size, mtime int64, files []string, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if !info.IsDir() {
+		return info.Size(), info.ModTime().UnixNano(), []string{path}, nil
+	}
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		mtime = max(mtime, fi.ModTime().UnixNano())
+		files = append(files, p)
+		return nil
+	})
+	sort.Strings(files)
+	return size, mtime, files, err
+}
+
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,184 @@
+package pipeline
+
+/*
+PIPELINE con reconstrucción incremental
+
+clean → remap → normalize → sim → recommend
+
+Cada etapa declara sus entradas, salidas y parámetros. Tras correrla se
+guarda en <artifacts>/manifest.json la firma (tamaño, mtime, sha256) de cada
+archivo y los parámetros usados. Una etapa se vuelve a correr solo si:
+  - no tiene registro en el manifest, o
+  - cambió algún parámetro, o
+  - cambió el contenido de alguna entrada, o
+  - falta alguna salida o su contenido ya no es el registrado.
+
+Como las entradas de una etapa son las salidas de la anterior, los cambios
+se propagan hacia abajo; si una etapa rehecha produce exactamente los mismos
+bytes, las siguientes se saltan.
+*/
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"time"
+)
+
+// Stage es una etapa lista para correr.
+type Stage struct {
+	Name    string            // clean | remap | normalize | sim | recommend
+	Key     string            // clave en el manifest (Name + variante, o la salida relativa a artifacts)
+	Inputs  []string          // archivos o directorios leídos
+	Opt     []string          // entradas opcionales: cuentan solo si existen al correr
+	Outputs []string          // archivos o directorios escritos
+	Params  map[string]string // flags que afectan el resultado
+	Report  string            // reporte de texto principal ("" si no hay)
+	Run     func() error
+}
+
+// Runner decide qué etapas correr y actualiza el manifest.
+type Runner struct {
+	Manifest *Manifest
+	Force    bool // correr aunque esté al día
+	DryRun   bool // solo informar
+}
+
+// Stale devuelve por qué la etapa debe volver a correr ("" si está al día).
+func (r *Runner) Stale(s Stage) (string, error) {
+	rec := r.Manifest.Stages[s.Key]
+	if rec == nil {
+		return "sin registro previo", nil
+	}
+	if !maps.Equal(rec.Params, s.Params) {
+		return "cambiaron los parámetros", nil
+	}
+	for _, in := range s.Inputs {
+		old, ok := rec.Inputs[in]
+		if !ok {
+			return "entrada nueva " + in, nil
+		}
+		cur, err := r.Manifest.sig(in)
+		if err != nil {
+			return "", fmt.Errorf("entrada %s: %w", in, err)
+		}
+		if cur.SHA256 != old.SHA256 {
+			return "cambió " + in, nil
+		}
+	}
+	for _, in := range s.Opt {
+		old, had := rec.Inputs[in]
+		cur, err := r.Manifest.sig(in)
+		if errors.Is(err, fs.ErrNotExist) {
+			if had {
+				return "desapareció " + in, nil
+			}
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("entrada %s: %w", in, err)
+		}
+		if !had {
+			return "entrada nueva " + in, nil
+		}
+		if cur.SHA256 != old.SHA256 {
+			return "cambió " + in, nil
+		}
+	}
+	for _, out := range s.Outputs {
+		old, ok := rec.Outputs[out]
+		if !ok {
+			return "salida nueva " + out, nil
+		}
+		cur, err := r.Manifest.sig(out)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "falta " + out, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if cur.SHA256 != old.SHA256 {
+			return "se modificó " + out, nil
+		}
+	}
+	return "", nil
+}
+
+// Run corre las etapas en orden, saltando las que están al día.
+func (r *Runner) Run(stages []Stage) error {
+	pending := false // en dry-run, una etapa previa sin correr invalida el resto
+	for _, s := range stages {
+		reason := "forzado"
+		if !r.Force {
+			var err error
+			if reason, err = r.Stale(s); err != nil {
+				return fmt.Errorf("%s: %w", s.Key, err)
+			}
+			if reason == "" && pending {
+				reason = "depende de una etapa previa pendiente"
+			}
+		}
+		if reason == "" {
+			fmt.Printf("[SKIP] %s (al día)\n", s.Key)
+			continue
+		}
+		if r.DryRun {
+			fmt.Printf("[PLAN] %s: %s\n", s.Key, reason)
+			pending = true
+			continue
+		}
+		fmt.Printf("[RUN] %s: %s\n", s.Key, reason)
+		t0 := time.Now()
+		if err := s.Run(); err != nil {
+			return fmt.Errorf("%s: %w", s.Key, err)
+		}
+		if err := r.record(s, time.Since(t0)); err != nil {
+			return fmt.Errorf("%s: actualizar manifest: %w", s.Key, err)
+		}
+	}
+	return nil
+}
+
+// record firma entradas y salidas y guarda el manifest.
+func (r *Runner) record(s Stage, d time.Duration) error {
+	rec := &Record{
+		Stage:    s.Name,
+		Params:   s.Params,
+		Inputs:   map[string]FileSig{},
+		Outputs:  map[string]FileSig{},
+		Finished: time.Now(),
+		Duration: d.Round(time.Millisecond).String(),
+	}
+	for _, in := range s.Inputs {
+		sg, err := r.Manifest.sig(in)
+		if err != nil {
+			return err
+		}
+		rec.Inputs[in] = sg
+	}
+	for _, in := range s.Opt {
+		sg, err := r.Manifest.sig(in)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		rec.Inputs[in] = sg
+	}
+	// las salidas se acaban de escribir: no reutilizar firmas viejas
+	delete(r.Manifest.Stages, s.Key)
+	for _, out := range s.Outputs {
+		sg, err := r.Manifest.sig(out)
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("la etapa no generó %s", out)
+		}
+		if err != nil {
+			return err
+		}
+		rec.Outputs[out] = sg
+	}
+	r.Manifest.Stages[s.Key] = rec
+	return r.Manifest.Save()
+}
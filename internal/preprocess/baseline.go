@@ -0,0 +1,233 @@
+package preprocess
+
+/*
+BASELINE: r ≈ μ + b_u + b_i con sesgos regularizados (--axis=baseline)
+
+Entrada: la misma que normalize (ratings_ui.bin o ratings_ui.csv).
+
+Salidas:
+  - <artifacts>/baseline.json          // μ, λ, iteraciones, RMSE de entrenamiento por iteración
+  - <artifacts>/user_bias.csv          (idx,bias)
+  - <artifacts>/item_bias.csv          (idx,bias)
+  - <artifacts>/matrix_baseline_csr/   // filas = usuarios, valor = r - (μ + b_u + b_i)
+  - <artifacts>/normalize_baseline_report.txt (+ .json)
+
+Ajuste por mínimos cuadrados alternados sobre los sesgos (Koren, 2008):
+
+  b_i = Σ_u (r_ui - μ - b_u) / (λ_i + n_i)
+  b_u = Σ_i (r_ui - μ - b_i) / (λ_u + n_u)
+
+Cada iteración recorre los triplets dos veces (una por ítems, otra por
+usuarios); solo los sesgos y contadores (O(U+I)) quedan en RAM. El RMSE de
+cada iteración sale de la pasada de usuarios sin recorrer de nuevo:
+Σ(e - b_u)² = Σe² - 2·b_u·Σe + n_u·b_u².
+
+Los residuos van a un CSR por usuario: sim --input=baseline_csr calcula
+similitudes sobre ellos y recommend --baseline predice
+b_ui + Σ s·(r - b)/Σ|s|.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/csr"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+)
+
+// AxisBaseline es el valor de --axis que ajusta los sesgos.
+const AxisBaseline = "baseline"
+
+// Baseline es el contenido de baseline.json.
+type Baseline struct {
+	Mu       float64   `json:"mu"`
+	RegUser  float64   `json:"reg_user"`
+	RegItem  float64   `json:"reg_item"`
+	Iters    int       `json:"iters"`
+	Users    int       `json:"users"`
+	Items    int       `json:"items"`
+	NNZ      int       `json:"nnz"`
+	RMSE     []float64 `json:"rmse"` // RMSE de entrenamiento tras cada iteración
+	UserBias string    `json:"user_bias"`
+	ItemBias string    `json:"item_bias"`
+	Residual string    `json:"residual_csr"`
+}
+
+func normalizeBaseline(l layout.Layout, opt NormalizeOptions) error {
+	if opt.Iters <= 0 {
+		return fmt.Errorf("--iters debe ser > 0 (recibido %d)", opt.Iters)
+	}
+	if opt.RegUser < 0 || opt.RegItem < 0 {
+		return fmt.Errorf("--reg_user y --reg_item no pueden ser negativos")
+	}
+	t0 := time.Now()
+	rep := report.New("normalize", opt)
+
+	sorter := newTripletSorter(l.TmpDir(), opt.MemLimit, byUserItem)
+	defer sorter.Close()
+
+	// --- PASO 1: μ, conteos por usuario/ítem y triplets en orden (u,i) ---
+	var userCnt, itemCnt []int
+	var sum, sumSq float64
+	U, I, NNZ := 0, 0, 0
+	src, err := eachTriplet(l, opt.TS, func(tr Triplet) error {
+		NNZ++
+		sum += tr.R
+		sumSq += tr.R * tr.R
+		if tr.U+1 > U {
+			U = tr.U + 1
+			userCnt = grow(userCnt, U)
+		}
+		if tr.I+1 > I {
+			I = tr.I + 1
+			itemCnt = grow(itemCnt, I)
+		}
+		userCnt[tr.U]++
+		itemCnt[tr.I]++
+		return sorter.Add(tr)
+	})
+	if err != nil {
+		return err
+	}
+	if NNZ == 0 {
+		return fmt.Errorf("%s no tiene ratings", src)
+	}
+	mu := sum / float64(NNZ)
+	rep.Time("read", time.Since(t0))
+
+	// --- PASO 2: sesgos por mínimos cuadrados alternados ---
+	t1 := time.Now()
+	bu, bi := make([]float64, U), make([]float64, I)
+	acc := make([]float64, max(U, I))
+	sq := make([]float64, U)
+	b := Baseline{Mu: mu, RegUser: opt.RegUser, RegItem: opt.RegItem, Iters: opt.Iters, Users: U, Items: I, NNZ: NNZ}
+	for it := 0; it < opt.Iters; it++ {
+		clear(acc)
+		if _, err := eachTriplet(l, false, func(tr Triplet) error {
+			acc[tr.I] += tr.R - mu - bu[tr.U]
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i := range bi {
+			bi[i] = acc[i] / (opt.RegItem + float64(itemCnt[i]))
+		}
+
+		clear(acc)
+		clear(sq)
+		if _, err := eachTriplet(l, false, func(tr Triplet) error {
+			e := tr.R - mu - bi[tr.I]
+			acc[tr.U] += e
+			sq[tr.U] += e * e
+			return nil
+		}); err != nil {
+			return err
+		}
+		var sse float64
+		for u := range bu {
+			bu[u] = acc[u] / (opt.RegUser + float64(userCnt[u]))
+			sse += sq[u] - 2*bu[u]*acc[u] + float64(userCnt[u])*bu[u]*bu[u]
+		}
+		b.RMSE = append(b.RMSE, math.Sqrt(math.Max(0, sse)/float64(NNZ)))
+	}
+	rep.Time("fit", time.Since(t1))
+
+	// --- PASO 3: sesgos, baseline.json y CSR de residuos ---
+	t2 := time.Now()
+	b.UserBias, b.ItemBias, b.Residual = l.Bias(csr.AxisUser), l.Bias(csr.AxisItem), l.CSRDir(AxisBaseline)
+	if err := writeDense(b.UserBias, "bias", bu); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", b.UserBias, err)
+	}
+	if err := writeDense(b.ItemBias, "bias", bi); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", b.ItemBias, err)
+	}
+	if err := writeNormalized(sorter, opt.TS, csrSink{
+		dir:  b.Residual,
+		meta: csr.Meta{Users: U, Items: I, Axis: csr.AxisUser, Variant: csr.VariantResidual},
+		cell: func(t Triplet) (int, int32, float64) { return t.U, int32(t.I), t.R - (mu + bu[t.U] + bi[t.I]) },
+	}); err != nil {
+		return err
+	}
+	js, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.Baseline(), append(js, '\n'), 0o644); err != nil {
+		return err
+	}
+	rep.Time("write", time.Since(t2))
+	fmt.Printf("[OK] BASELINE -> μ=%.4f RMSE=%.4f (%d iteraciones)  out=%s%s\n",
+		mu, b.RMSE[len(b.RMSE)-1], opt.Iters, b.Residual, runsNote(sorter))
+
+	rep.Time("total", time.Since(t0))
+	rep.Count("users", int64(U))
+	rep.Count("items", int64(I))
+	rep.Count("nnz", int64(NNZ))
+	rep.Count("runs", int64(sorter.Runs()))
+	rep.Metric("global_mean", mu)
+	rep.Metric("train_rmse", b.RMSE[len(b.RMSE)-1])
+	// referencia: RMSE de predecir siempre μ (Σ(r-μ)² = Σr² - n·μ²)
+	rep.Metric("train_rmse_mean_only", math.Sqrt(math.Max(0, sumSq/float64(NNZ)-mu*mu)))
+	rep.Set("rmse_by_iter", b.RMSE)
+	rep.Input("triplets", src)
+	rep.Output("baseline", l.Baseline())
+	rep.Output("user_bias", b.UserBias)
+	rep.Output("item_bias", b.ItemBias)
+	rep.Output("residual_csr", b.Residual)
+	return writeBaselineReport(l.NormalizeReport(AxisBaseline), b, rep)
+}
+
+func writeBaselineReport(path string, b Baseline, rep *report.Report) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "== NORMALIZE (baseline μ + b_u + b_i) ==\n")
+	fmt.Fprintf(&sb, "Entrada           : %s\n", rep.Inputs["triplets"])
+	fmt.Fprintf(&sb, "Usuarios (U)      : %d\n", b.Users)
+	fmt.Fprintf(&sb, "Items (I)         : %d\n", b.Items)
+	fmt.Fprintf(&sb, "Ratings (NNZ)     : %d\n", b.NNZ)
+	fmt.Fprintf(&sb, "λ usuario / ítem  : %g / %g\n", b.RegUser, b.RegItem)
+	fmt.Fprintf(&sb, "Media global (μ)  : %.4f\n\n", b.Mu)
+	fmt.Fprintf(&sb, "RMSE de entrenamiento:\n")
+	fmt.Fprintf(&sb, "  solo μ          : %.4f\n", rep.Metrics["train_rmse_mean_only"])
+	for k, r := range b.RMSE {
+		fmt.Fprintf(&sb, "  iteración %-5d : %.4f\n", k+1, r)
+	}
+	fmt.Fprintf(&sb, "\nTiempos:\n")
+	for _, ph := range []struct{ key, label string }{
+		{"read", "Leer triplets"}, {"fit", "Ajustar sesgos"}, {"write", "Escribir"}, {"total", "TOTAL"},
+	} {
+		fmt.Fprintf(&sb, "  %-14s: %.3fs\n", ph.label, rep.Timings[ph.key])
+	}
+	fmt.Fprintf(&sb, "\nSalidas:\n  %s\n  %s\n  %s\n  %s\n", rep.Outputs["baseline"], b.UserBias, b.ItemBias, b.Residual)
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+	return rep.Write(report.Path(path))
+}
+
+// writeDense escribe idx,<name> para cada posición de vals.
+func writeDense(path, name string, vals []float64) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{"idx", name})
+	for i, v := range vals {
+		_ = w.Write([]string{strconv.Itoa(i), strconv.FormatFloat(v, 'f', -1, 64)})
+	}
+	w.Flush()
+	return errors.Join(w.Error(), f.Close())
+}
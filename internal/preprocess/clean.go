@@ -0,0 +1,1257 @@
+package preprocess
+
+/*
+LIMPIEZA / INSPECCIÓN + FILTRADO (k-core configurable)
+
+Objetivo:
+- Explorar y diagnosticar el dataset (sin modificar datos) y,
+- Aplicar limpieza efectiva reteniendo solo filas de películas con
+  ≥--min_item_ratings (5 por defecto) y usuarios con ≥--min_user_ratings
+  (0 = sin filtro), generando artifacts/ratings_min5.csv y un reporte de filtrado.
+
+Tareas:
+1) Inspección (igual que antes):
+   - Nulos / campos vacíos
+   - Rango de rating [0.5, 5.0] con paso 0.5
+   - Duplicados (userId, movieId) contiguos
+   - Distribuciones e insights
+   - Reporte: <artifacts>/clean_report.txt (+ .json, ver internal/report)
+
+0) Importación (solo --format distinto de movielens): el archivo de origen
+   (u.data, ratings.dat, CSV/TSV/JSONL con --columns) se convierte a
+   <artifacts>/ratings_imported.csv con el formato de ratings.csv y las
+   etapas 1 y 2 leen ese archivo (ver internal/importers).
+
+2) Filtrado real:
+   - Cargar pares (usuario, película) con ids densos (1ra pasada)
+   - Duplicados (userId, movieId) en todo el archivo, no solo contiguos:
+     se ordenan los pares y cada grupo repetido se resuelve con --dedup
+       first            primera fila del archivo (por defecto)
+       last             última fila del archivo
+       latest_timestamp fila con el timestamp más reciente (empate: la última)
+       mean             primera fila con el promedio de los ratings del grupo,
+                        redondeado a media estrella si todos lo eran
+                        (así remap --rating_type=uint8 puede cuantizarlo)
+     (rating/timestamp de las filas repetidas se releen solo si las hay)
+   - Perfiles sospechosos (entropía, desvío, ráfagas, volumen): se listan en
+     <artifacts>/suspicious_users.csv y, con --exclude_suspicious, se
+     quitan antes de las rondas (ver suspicious.go)
+   - Rondas: contar ratings vivos por usuario/película y descartar los que
+     quedan bajo su umbral; con --kcore se repite hasta que una ronda no
+     elimina nada, si no, una sola ronda (criterio histórico)
+   - Escribir solo filas cuyo usuario y película sobreviven (2da pasada)
+   - Guardar CSV limpio: <artifacts>/ratings_min5.csv
+   - Guardar reporte filtrado: <artifacts>/clean_filter_report.txt (+ .json)
+   - Imprimir resumen (filas/usuarios/películas eliminados por ronda, justificación)
+
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/importers"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+	"pc3/utils"
+)
+
+// Clean inspecciona <data>/ratings.csv (o el archivo importado según
+// opt.Format) y escribe el CSV filtrado según opt junto con ambos reportes.
+func Clean(l layout.Layout, opt CleanOptions) error {
+	if !slices.Contains(DedupPolicies, opt.Dedup) {
+		return fmt.Errorf("--dedup debe ser %s (recibido %q)", strings.Join(DedupPolicies, " | "), opt.Dedup)
+	}
+	if err := opt.Suspicious.Validate(); err != nil {
+		return err
+	}
+	log := utils.NewLogger(true)
+	timer := utils.NewTimer()
+
+	// Asegurar directorio de artifacts para ambos reportes y el CSV limpio
+	if err := os.MkdirAll(l.Artifacts, 0o755); err != nil {
+		return fmt.Errorf("no se pudo crear %s: %w", l.Artifacts, err)
+	}
+
+	// ==================== ETAPA 0: IMPORTACIÓN ====================
+	src, err := RatingsSource(l, opt)
+	if err != nil {
+		return err
+	}
+	ratingsPath, imported, err := importRatings(l, opt, src, log)
+	if err != nil {
+		return fmt.Errorf("error importando %s: %w", src, err)
+	}
+
+	// ==================== ETAPA 1: INSPECCIÓN ====================
+	log.Info("Inicio de inspección…")
+	stats, err := inspectRatings(ratingsPath, log)
+	if err != nil {
+		return fmt.Errorf("error inspeccionando ratings: %w", err)
+	}
+
+	totalMovies, err := countMovies(l.Movies(), log)
+	if err != nil {
+		log.Warn("no se pudo contar movies (%v) — no es crítico para esta etapa", err)
+	}
+
+	// Consola + reporte
+	printConsoleSummary(stats, totalMovies, log)
+	if err := writeReport(stats, totalMovies, src, opt.Format, imported, l.CleanReport()); err != nil {
+		return fmt.Errorf("no se pudo escribir reporte de inspección: %w", err)
+	}
+
+	// ==================== ETAPA 2: FILTRADO REAL (k-core) ====================
+	if err := filterByPopularity(l, ratingsPath, opt, log); err != nil {
+		return fmt.Errorf("falló el filtrado real: %w", err)
+	}
+
+	log.Info("Listo. Reportes en %s. Tiempo total: %s", l.Artifacts, timer.Elapsed())
+	return nil
+}
+
+// FormatMovieLens es el formato nativo (ratings.csv de MovieLens 25M): se
+// lee directamente, sin importar.
+const FormatMovieLens = "movielens"
+
+// RatingsSource es el archivo de origen de clean: --source si se pasó, si no
+// <data>/ratings.csv (movielens) o el archivo por defecto del importador.
+func RatingsSource(l layout.Layout, opt CleanOptions) (string, error) {
+	if opt.Source != "" {
+		return opt.Source, nil
+	}
+	if opt.Format == FormatMovieLens {
+		return l.Ratings(), nil
+	}
+	imp, err := importers.ByName(opt.Format)
+	if err != nil {
+		return "", fmt.Errorf("--format: %w", err)
+	}
+	if imp.File() == "" {
+		return "", fmt.Errorf("--format=%s requiere --source", opt.Format)
+	}
+	return l.DataFile(imp.File()), nil
+}
+
+// importRatings convierte src a l.Imported() si el formato no es el nativo
+// y devuelve la ruta que leen inspección y filtrado (nil stats = sin importar).
+func importRatings(l layout.Layout, opt CleanOptions, src string, log *utils.Logger) (string, *importers.Stats, error) {
+	if opt.Format == FormatMovieLens {
+		return src, nil, nil
+	}
+	imp, err := importers.ByName(opt.Format)
+	if err != nil {
+		return "", nil, err
+	}
+	// correspondencias de una corrida anterior no deben sobrevivir
+	if err := os.RemoveAll(l.ImportDir()); err != nil {
+		return "", nil, err
+	}
+	log.Info("Importando %s (--format=%s)…", src, opt.Format)
+	st, err := importers.Convert(imp, src, l.Imported(), l.ImportDir(),
+		importers.Options{Columns: opt.Columns, Header: opt.Header})
+	if err != nil {
+		return "", nil, err
+	}
+	if st.Rows == 0 {
+		return "", nil, fmt.Errorf("ninguna fila válida (%d descartadas); revisar --format/--columns", st.Skipped)
+	}
+	log.Info("Importadas %d filas (%d descartadas), %d usuarios, %d ítems -> %s",
+		st.Rows, st.Skipped, st.Users, st.Items, l.Imported())
+	if st.MappedUsers || st.MappedItems {
+		log.Info("Ids no numéricos renumerados: correspondencias en %s", l.ImportDir())
+	}
+	return l.Imported(), &st, nil
+}
+
+// ----- Estructuras de resumen (inspección) -----
+
+type RatingsStats struct {
+	TotalRows        int64
+	TotalValidRows   int64
+	TotalUsers       int
+	TotalItems       int
+	NullRows         int64
+	OutOfRangeRows   int64
+	NonStepRows      int64
+	Duplicates       int64
+	RatingBuckets    map[float64]int64 // 0.5, 1.0, …, 5.0
+	StarBuckets      map[int]int64     // 1,2,3,4,5 (estrellas enteras)
+	UserActivity     map[int]int       // userId -> count
+	ItemActivity     map[int]int       // movieId -> count
+	UserBuckets      map[string]int    // actividad por usuario (rangos)
+	ItemBuckets      map[string]int    // actividad por ítem (rangos)
+	UserMin, UserMax int
+	ItemMin, ItemMax int
+	UserAvg, ItemAvg float64
+
+	// Insights / umbrales
+	UsersLt5   int
+	UsersGe100 int
+	ItemsLt5   int
+	ItemsLt10  int
+	ItemsGe100 int
+}
+
+func newRatingsStats() *RatingsStats {
+	buckets := map[float64]int64{}
+	for r := 0.5; r <= 5.0+1e-9; r += 0.5 {
+		buckets[roundHalf(r)] = 0
+	}
+	star := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	return &RatingsStats{
+		RatingBuckets: buckets,
+		StarBuckets:   star,
+		UserActivity:  make(map[int]int, 200000),
+		ItemActivity:  make(map[int]int, 70000),
+	}
+}
+
+func roundHalf(x float64) float64 {
+	s := fmt.Sprintf("%.1f", x) // evita errores de flotantes
+	v, _ := strconv.ParseFloat(s, 64)
+	return v
+}
+
+func validStep(r float64) bool {
+	const eps = 1e-9
+	halfSteps := r / 0.5
+	return mathAbs(halfSteps-mathRound(halfSteps)) < eps
+}
+
+func mathRound(x float64) float64 {
+	if x >= 0 {
+		return float64(int64(x + 0.5))
+	}
+	return float64(int64(x - 0.5))
+}
+
+func mathAbs(x float64) float64 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// ----- Lectura e inspección de ratings -----
+
+func inspectRatings(path string, log *utils.Logger) (*RatingsStats, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1 // acepta filas con comas entrecomilladas
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("leer cabecera: %w", err)
+	}
+	if len(header) < 4 {
+		return nil, errors.New("cabecera inesperada en ratings.csv (se esperan 4 columnas)")
+	}
+
+	stats := newRatingsStats()
+
+	// Detector de duplicados con baja memoria (consecutivos)
+	var prevUser, prevItem int
+	var havePrev bool
+
+	rowIdx := int64(0)
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			// otras anormalidades: cuenta como nulo y sigue
+			stats.NullRows++
+			continue
+		}
+		rowIdx++
+
+		if len(row) < 4 {
+			stats.NullRows++
+			continue
+		}
+
+		uidStr := strings.TrimSpace(row[0])
+		iidStr := strings.TrimSpace(row[1])
+		rStr := strings.TrimSpace(row[2])
+
+		if uidStr == "" || iidStr == "" || rStr == "" {
+			stats.NullRows++
+			continue
+		}
+
+		uid, err1 := strconv.Atoi(uidStr)
+		iid, err2 := strconv.Atoi(iidStr)
+		r, err3 := strconv.ParseFloat(rStr, 64)
+
+		if err1 != nil || err2 != nil || err3 != nil {
+			stats.NullRows++
+			continue
+		}
+
+		// rango y pasos
+		if r < 0.5 || r > 5.0 {
+			stats.OutOfRangeRows++
+		} else if !validStep(r) {
+			stats.NonStepRows++
+		} else {
+			stats.TotalValidRows++
+			stats.RatingBuckets[roundHalf(r)]++
+			// estrellas enteras (e.g., 3.5 se suma a 4)
+			star := int(mathRound(r))
+			if star < 1 {
+				star = 1
+			}
+			if star > 5 {
+				star = 5
+			}
+			stats.StarBuckets[star]++
+
+			// actividad
+			stats.UserActivity[uid]++
+			stats.ItemActivity[iid]++
+		}
+
+		// duplicados (consecutivos)
+		if havePrev && uid == prevUser && iid == prevItem {
+			stats.Duplicates++
+		}
+		prevUser, prevItem, havePrev = uid, iid, true
+
+		stats.TotalRows++
+		if rowIdx%2_000_000 == 0 {
+			log.Info("leídas ~%d filas…", rowIdx)
+		}
+	}
+
+	// Estadísticos de actividad por usuario/ítem
+	stats.TotalUsers = len(stats.UserActivity)
+	stats.TotalItems = len(stats.ItemActivity)
+	stats.UserMin, stats.UserMax, stats.UserAvg, stats.UserBuckets = summarizeActivity(stats.UserActivity)
+	stats.ItemMin, stats.ItemMax, stats.ItemAvg, stats.ItemBuckets = summarizeActivity(stats.ItemActivity)
+
+	// Insights / umbrales
+	stats.UsersLt5, stats.UsersGe100 = countThresholds(stats.UserActivity, 5, 100)
+	tmpLt5, tmpLt10, tmpGe100 := countThresholdsItems(stats.ItemActivity, 5, 10, 100)
+	stats.ItemsLt5, stats.ItemsLt10, stats.ItemsGe100 = tmpLt5, tmpLt10, tmpGe100
+
+	return stats, nil
+}
+
+func summarizeActivity(m map[int]int) (min int, max int, avg float64, buckets map[string]int) {
+	if len(m) == 0 {
+		return 0, 0, 0.0, map[string]int{}
+	}
+	min = 1<<31 - 1
+	max = -1
+	var sum int
+	for _, c := range m {
+		if c < min {
+			min = c
+		}
+		if c > max {
+			max = c
+		}
+		sum += c
+	}
+	avg = float64(sum) / float64(len(m))
+	// buckets para informe
+	buckets = map[string]int{
+		"0-4":   0,
+		"5-9":   0,
+		"10-19": 0,
+		"20-49": 0,
+		"50-99": 0,
+		"100+":  0,
+	}
+	for _, c := range m {
+		switch {
+		case c <= 4:
+			buckets["0-4"]++
+		case c <= 9:
+			buckets["5-9"]++
+		case c <= 19:
+			buckets["10-19"]++
+		case c <= 49:
+			buckets["20-49"]++
+		case c <= 99:
+			buckets["50-99"]++
+		default:
+			buckets["100+"]++
+		}
+	}
+	return
+}
+
+func countMovies(path string, log *utils.Logger) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+	reader := csv.NewReader(bufio.NewReader(f))
+	_, err = reader.Read() // header
+	if err != nil {
+		return 0, fmt.Errorf("leer cabecera: %w", err)
+	}
+	count := 0
+	for {
+		_, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return 0, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func countThresholds(m map[int]int, lt int, ge int) (ltCount int, geCount int) {
+	for _, c := range m {
+		if c < lt {
+			ltCount++
+		}
+		if c >= ge {
+			geCount++
+		}
+	}
+	return
+}
+
+func countThresholdsItems(m map[int]int, ltA int, ltB int, geC int) (ltACount, ltBCount, geCCount int) {
+	for _, c := range m {
+		if c < ltA {
+			ltACount++
+		}
+		if c < ltB {
+			ltBCount++
+		}
+		if c >= geC {
+			geCCount++
+		}
+	}
+	return
+}
+
+// ----- Reporte / Consola (inspección) -----
+
+func printConsoleSummary(s *RatingsStats, totalMovies int, log *utils.Logger) {
+	log.Info("Resumen general:")
+	log.Info("  Filas totales: %d | válidas: %d | nulas: %d | fuera de rango: %d | pasos inválidos: %d | duplicados: %d",
+		s.TotalRows, s.TotalValidRows, s.NullRows, s.OutOfRangeRows, s.NonStepRows, s.Duplicates)
+	log.Info("  Usuarios distintos (con al menos 1 rating): %d", s.TotalUsers)
+	log.Info("  Películas distintas con rating: %d (en movies.csv hay %d)", s.TotalItems, totalMovies)
+
+	log.Info("Distribución de ratings (paso 0.5):")
+	keys := make([]float64, 0, len(s.RatingBuckets))
+	for k := range s.RatingBuckets {
+		keys = append(keys, k)
+	}
+	sort.Float64s(keys)
+	for _, k := range keys {
+		log.Info("  rating %.1f : %d", k, s.RatingBuckets[k])
+	}
+
+	log.Info("Distribución por estrellas enteras (1–5):")
+	for star := 1; star <= 5; star++ {
+		log.Info("  %d★ : %d", star, s.StarBuckets[star])
+	}
+
+	log.Info("Actividad por usuario (número de películas calificadas por usuario):")
+	log.Info("  min=%d | max=%d | avg=%.2f", s.UserMin, s.UserMax, s.UserAvg)
+	log.Info("  Buckets = usuarios que calificaron dentro del rango indicado:")
+	printBucketsExplained("Usuarios por buckets", s.UserBuckets, log)
+
+	log.Info("Actividad por película (número de ratings por película):")
+	log.Info("  min=%d | max=%d | avg=%.2f", s.ItemMin, s.ItemMax, s.ItemAvg)
+	log.Info("  Buckets = películas que recibieron una cantidad de ratings dentro del rango indicado:")
+	printBucketsExplained("Ítems por buckets", s.ItemBuckets, log)
+
+	// Insights / umbrales
+	uPctLt5 := 100.0 * float64(s.UsersLt5) / float64(max(1, s.TotalUsers))
+	uPctGe100 := 100.0 * float64(s.UsersGe100) / float64(max(1, s.TotalUsers))
+	iPctLt5 := 100.0 * float64(s.ItemsLt5) / float64(max(1, s.TotalItems))
+	iPctLt10 := 100.0 * float64(s.ItemsLt10) / float64(max(1, s.TotalItems))
+	iPctGe100 := 100.0 * float64(s.ItemsGe100) / float64(max(1, s.TotalItems))
+
+	log.Info("Insights / umbrales sugeridos:")
+	log.Info("  Usuarios con <5 ratings: %d (%.2f%%) — en ML-25M debería ser ~0 porque ya filtran <20.", s.UsersLt5, uPctLt5)
+	log.Info("  Usuarios con ≥100 ratings: %d (%.2f%%).", s.UsersGe100, uPctGe100)
+	log.Info("  Películas con <5 ratings: %d (%.2f%%).", s.ItemsLt5, iPctLt5)
+	log.Info("  Películas con <10 ratings: %d (%.2f%%).", s.ItemsLt10, iPctLt10)
+	log.Info("  Películas con ≥100 ratings: %d (%.2f%%).", s.ItemsGe100, iPctGe100)
+}
+
+func writeReport(s *RatingsStats, totalMovies int, src, format string, imported *importers.Stats, out string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "== INSPECCIÓN MovieLens 25M ==\n\n")
+	fmt.Fprintf(&b, "Fuente                 : %s (--format=%s)\n", src, format)
+	if imported != nil {
+		fmt.Fprintf(&b, "Importadas             : %d filas (%d descartadas al convertir)\n", imported.Rows, imported.Skipped)
+		fmt.Fprintf(&b, "Ids renumerados        : usuarios=%v ítems=%v\n", imported.MappedUsers, imported.MappedItems)
+	}
+	fmt.Fprintf(&b, "\n")
+	fmt.Fprintf(&b, "Filas totales          : %d\n", s.TotalRows)
+	fmt.Fprintf(&b, "Filas válidas          : %d\n", s.TotalValidRows)
+	fmt.Fprintf(&b, "Nulos                  : %d\n", s.NullRows)
+	fmt.Fprintf(&b, "Fuera de rango         : %d\n", s.OutOfRangeRows)
+	fmt.Fprintf(&b, "Pasos inválidos        : %d\n", s.NonStepRows)
+	fmt.Fprintf(&b, "Duplicados (consecut.) : %d (todos los pares repetidos: ver clean_filter_report.txt)\n\n", s.Duplicates)
+
+	fmt.Fprintf(&b, "Usuarios distintos     : %d\n", s.TotalUsers)
+	fmt.Fprintf(&b, "Películas (ratings)    : %d\n", s.TotalItems)
+	fmt.Fprintf(&b, "Películas (movies.csv) : %d\n\n", totalMovies)
+
+	fmt.Fprintf(&b, "-- Distribución de ratings (paso 0.5) --\n")
+	keys := make([]float64, 0, len(s.RatingBuckets))
+	for k := range s.RatingBuckets {
+		keys = append(keys, k)
+	}
+	sort.Float64s(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "  %.1f : %d\n", k, s.RatingBuckets[k])
+	}
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "-- Distribución por estrellas enteras (1–5) --\n")
+	for star := 1; star <= 5; star++ {
+		fmt.Fprintf(&b, "  %d★ : %d\n", star, s.StarBuckets[star])
+	}
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "-- Actividad por usuario (número de películas calificadas por usuario) --\n")
+	fmt.Fprintf(&b, "min=%d max=%d avg=%.2f\n", s.UserMin, s.UserMax, s.UserAvg)
+	writeBuckets(&b, s.UserBuckets)
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "-- Actividad por película (número de ratings por película) --\n")
+	fmt.Fprintf(&b, "min=%d max=%d avg=%.2f\n", s.ItemMin, s.ItemMax, s.ItemAvg)
+	writeBuckets(&b, s.ItemBuckets)
+	fmt.Fprintf(&b, "\n")
+
+	// Insights / umbrales
+	uPctLt5 := 100.0 * float64(s.UsersLt5) / float64(max(1, s.TotalUsers))
+	uPctGe100 := 100.0 * float64(s.UsersGe100) / float64(max(1, s.TotalUsers))
+	iPctLt5 := 100.0 * float64(s.ItemsLt5) / float64(max(1, s.TotalItems))
+	iPctLt10 := 100.0 * float64(s.ItemsLt10) / float64(max(1, s.TotalItems))
+	iPctGe100 := 100.0 * float64(s.ItemsGe100) / float64(max(1, s.TotalItems))
+
+	fmt.Fprintf(&b, "-- Insights / umbrales sugeridos --\n")
+	fmt.Fprintf(&b, "Usuarios con <5 ratings   : %d (%.2f%%) — en ML-25M debería ser ~0.\n", s.UsersLt5, uPctLt5)
+	fmt.Fprintf(&b, "Usuarios con ≥100 ratings : %d (%.2f%%)\n", s.UsersGe100, uPctGe100)
+	fmt.Fprintf(&b, "Películas con <5 ratings  : %d (%.2f%%)\n", s.ItemsLt5, iPctLt5)
+	fmt.Fprintf(&b, "Películas con <10 ratings : %d (%.2f%%)\n", s.ItemsLt10, iPctLt10)
+	fmt.Fprintf(&b, "Películas con ≥100 ratings: %d (%.2f%%)\n", s.ItemsGe100, iPctGe100)
+
+	if err := os.WriteFile(out, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+
+	rep := report.New("clean", nil)
+	rep.Input("ratings", src)
+	rep.Set("format", format)
+	if imported != nil {
+		rep.Set("import", imported)
+	}
+	rep.Count("rows", s.TotalRows)
+	rep.Count("valid_rows", s.TotalValidRows)
+	rep.Count("null_rows", s.NullRows)
+	rep.Count("out_of_range_rows", s.OutOfRangeRows)
+	rep.Count("non_step_rows", s.NonStepRows)
+	rep.Count("consecutive_duplicates", s.Duplicates)
+	rep.Count("users", int64(s.TotalUsers))
+	rep.Count("items", int64(s.TotalItems))
+	rep.Count("movies_csv", int64(totalMovies))
+	rep.Count("users_lt5", int64(s.UsersLt5))
+	rep.Count("users_ge100", int64(s.UsersGe100))
+	rep.Count("items_lt5", int64(s.ItemsLt5))
+	rep.Count("items_lt10", int64(s.ItemsLt10))
+	rep.Count("items_ge100", int64(s.ItemsGe100))
+	rep.Metric("user_avg", s.UserAvg)
+	rep.Metric("item_avg", s.ItemAvg)
+	ratingHist := map[string]int64{}
+	for k, v := range s.RatingBuckets {
+		ratingHist[fmt.Sprintf("%.1f", k)] = v
+	}
+	rep.Set("rating_histogram", ratingHist)
+	rep.Set("star_histogram", s.StarBuckets)
+	rep.Set("user_activity", map[string]any{"min": s.UserMin, "max": s.UserMax, "buckets": s.UserBuckets})
+	rep.Set("item_activity", map[string]any{"min": s.ItemMin, "max": s.ItemMax, "buckets": s.ItemBuckets})
+	return rep.Write(report.Path(out))
+}
+
+func printBucketsExplained(label string, m map[string]int, log *utils.Logger) {
+	ordered := []string{"0-4", "5-9", "10-19", "20-49", "50-99", "100+"}
+	log.Info("%s (interpretación):", label)
+	log.Info("  0-4    = cantidad de entidades con entre 0 y 4 registros (usuarios: películas calificadas; ítems: ratings recibidos)")
+	log.Info("  5-9    = … entre 5 y 9")
+	log.Info("  10-19  = … entre 10 y 19")
+	log.Info("  20-49  = … entre 20 y 49")
+	log.Info("  50-99  = … entre 50 y 99")
+	log.Info("  100+   = … 100 o más")
+	for _, k := range ordered {
+		log.Info("    %-5s : %d", k, m[k])
+	}
+}
+
+func writeBuckets(b *strings.Builder, m map[string]int) {
+	labels := []string{"0-4", "5-9", "10-19", "20-49", "50-99", "100+"}
+	for _, k := range labels {
+		fmt.Fprintf(b, "  %-6s : %d\n", k, m[k])
+	}
+}
+
+// utilidades pequeñas
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ====================== ETAPA 2: FILTRADO REAL (k-core) ======================
+
+// CleanOptions son los umbrales del filtrado.
+type CleanOptions struct {
+	MinItemRatings int    `json:"min_item_ratings"` // conservar películas con ≥N ratings
+	MinUserRatings int    `json:"min_user_ratings"` // conservar usuarios con ≥N ratings (0 = sin filtro)
+	KCore          bool   `json:"kcore"`            // repetir hasta que nadie quede bajo su umbral
+	Dedup          string `json:"dedup"`            // first | last | latest_timestamp | mean
+
+	// perfiles sospechosos (ver suspicious.go)
+	Suspicious        SuspiciousOptions `json:"suspicious"`
+	ExcludeSuspicious bool              `json:"exclude_suspicious"` // quitarlos antes de las rondas
+
+	// origen (ver internal/importers)
+	Format  string `json:"format"`            // movielens | ml100k | ml1m | ml10m | csv | tsv | jsonl
+	Source  string `json:"source,omitempty"`  // archivo de origen (por defecto el del formato en --data)
+	Columns string `json:"columns,omitempty"` // csv/tsv/jsonl: role=columna,…
+	Header  bool   `json:"header"`            // csv/tsv: la primera fila es cabecera
+}
+
+// DedupPolicies son los valores válidos de CleanOptions.Dedup.
+var DedupPolicies = []string{"first", "last", "latest_timestamp", "mean"}
+
+// DefaultCleanOptions es el criterio histórico: películas con ≥5 ratings,
+// una sola pasada y sin filtrar usuarios; de cada duplicado queda la
+// primera fila. El origen es <data>/ratings.csv.
+func DefaultCleanOptions() CleanOptions {
+	return CleanOptions{MinItemRatings: 5, Dedup: "first", Format: FormatMovieLens, Header: true,
+		Suspicious: DefaultSuspiciousOptions()}
+}
+
+func (o CleanOptions) mode() string {
+	if o.KCore {
+		return "k-core iterativo hasta estabilizar"
+	}
+	return "una sola pasada"
+}
+
+// filterRound resume una ronda: lo eliminado y lo que queda después.
+type filterRound struct {
+	DroppedRows  int64 `json:"dropped_rows"`
+	DroppedUsers int   `json:"dropped_users"`
+	DroppedItems int   `json:"dropped_items"`
+	Rows         int64 `json:"rows"`
+	Users        int   `json:"users"`
+	Items        int   `json:"items"`
+}
+
+// ratingPairs guarda (usuario, película) de cada fila con ids densos
+// (2×int32 por fila) para iterar el k-core en memoria sin releer el CSV.
+// Las filas se numeran en orden de archivo contando solo las parseables.
+type ratingPairs struct {
+	u, i    []int32
+	userIdx map[int]int32 // userId -> denso
+	itemIdx map[int]int32 // movieId -> denso
+
+	drop   []bool            // filas descartadas por duplicadas (nil = ninguna)
+	rating map[int32]float64 // rating reemplazado (--dedup=mean)
+}
+
+// dupStats cuenta los duplicados resueltos.
+type dupStats struct {
+	Rows       int64 `json:"rows"`        // filas leídas (con duplicados)
+	Groups     int64 `json:"groups"`      // pares (usuario, película) con más de una fila
+	Removed    int64 `json:"removed"`     // filas descartadas
+	Contiguous int64 `json:"contiguous"`  // repeticiones pegadas a la fila anterior del mismo par
+	Scattered  int64 `json:"scattered"`   // repeticiones en otra parte del archivo
+	Identical  int64 `json:"identical"`   // grupos con el mismo rating y timestamp
+	SameRating int64 `json:"same_rating"` // grupos con el mismo rating y distinto timestamp
+	Conflict   int64 `json:"conflict"`    // grupos con ratings distintos
+}
+
+func filterByPopularity(l layout.Layout, ratingsPath string, opt CleanOptions, log *utils.Logger) error {
+	filteredPath, filterReport := l.Filtered(), l.FilterReport()
+
+	t0 := time.Now()
+	log.Info("=== FILTRADO REAL: películas con ≥%d ratings, usuarios con ≥%d ratings (%s) ===",
+		opt.MinItemRatings, opt.MinUserRatings, opt.mode())
+
+	// 1) Pares (usuario, película) en memoria (1ª pasada)
+	pairs, err := loadPairs(ratingsPath)
+	if err != nil {
+		return fmt.Errorf("lectura de pares falló: %v", err)
+	}
+
+	// 1b) Duplicados (usuario, película) en todo el archivo
+	dups, err := dedup(ratingsPath, pairs, opt.Dedup)
+	if err != nil {
+		return fmt.Errorf("resolución de duplicados falló: %v", err)
+	}
+	if dups.Groups > 0 {
+		log.Info("Duplicados: %d pares repetidos, %d filas descartadas (--dedup=%s)", dups.Groups, dups.Removed, opt.Dedup)
+	}
+
+	// 1c) Perfiles sospechosos (ver suspicious.go)
+	flagged, suspects, err := detectSuspicious(ratingsPath, pairs, opt.Suspicious)
+	if err != nil {
+		return fmt.Errorf("detección de perfiles sospechosos falló: %v", err)
+	}
+	if err := writeSuspicious(l.Suspicious(), suspects); err != nil {
+		return fmt.Errorf("no se pudo escribir %s: %v", l.Suspicious(), err)
+	}
+	susp := summarizeSuspicious(suspects, opt.ExcludeSuspicious)
+	log.Info("Perfiles sospechosos: %d usuarios, %d filas -> %s", susp.Users, susp.Rows, l.Suspicious())
+	if !opt.ExcludeSuspicious {
+		flagged = nil
+	}
+
+	// 2) Rondas de filtrado
+	userAlive, itemAlive, start, excl, rounds := kcore(pairs, opt, flagged)
+	if flagged != nil {
+		log.Info("Exclusión de sospechosos: -%d filas, -%d usuarios, -%d películas (quedan %d / %d / %d)",
+			excl.DroppedRows, excl.DroppedUsers, excl.DroppedItems, excl.Rows, excl.Users, excl.Items)
+	}
+	for k, r := range rounds {
+		log.Info("Ronda %d: -%d filas, -%d usuarios, -%d películas (quedan %d / %d / %d)",
+			k+1, r.DroppedRows, r.DroppedUsers, r.DroppedItems, r.Rows, r.Users, r.Items)
+	}
+	final := rounds[len(rounds)-1]
+
+	// 3) Escritura filtrada (2ª pasada)
+	if err := os.MkdirAll(filepath.Dir(filteredPath), 0o755); err != nil {
+		return fmt.Errorf("crear dir salida: %w", err)
+	}
+	keptRows, err := writeFilteredRatings(ratingsPath, filteredPath, pairs, userAlive, itemAlive)
+	if err != nil {
+		return fmt.Errorf("escritura del CSV filtrado falló: %v", err)
+	}
+	if keptRows != final.Rows {
+		log.Warn("filas escritas (%d) difieren de las contadas (%d)", keptRows, final.Rows)
+	}
+
+	// 4) Reporte de filtrado
+	if err := writeFilterReport(filterReport, opt, dups, susp, start, excl, rounds, time.Since(t0)); err != nil {
+		return fmt.Errorf("no se pudo escribir el reporte de filtrado: %v", err)
+	}
+
+	// 5) Consola (resumen)
+	droppedRows := start.Rows - final.Rows
+	droppedItems := start.Items - final.Items
+	droppedUsers := start.Users - final.Users
+	log.Info("=== RESUMEN FILTRADO ===")
+	log.Info("Criterio: películas con ≥%d ratings, usuarios con ≥%d ratings (%s).", opt.MinItemRatings, opt.MinUserRatings, opt.mode())
+	log.Info("Rondas               : %d", len(rounds))
+	log.Info("Filas originales     : %d", start.Rows)
+	log.Info("Filas retenidas      : %d", final.Rows)
+	log.Info("Filas eliminadas     : %d (%.2f%%)", droppedRows, percent64(droppedRows, start.Rows))
+	log.Info("Películas totales    : %d", start.Items)
+	log.Info("Películas retenidas  : %d", final.Items)
+	log.Info("Películas eliminadas : %d (%.2f%%)", droppedItems, percent(droppedItems, start.Items))
+	log.Info("Usuarios totales     : %d", start.Users)
+	log.Info("Usuarios retenidos   : %d", final.Users)
+	log.Info("Usuarios eliminados  : %d (%.2f%%)", droppedUsers, percent(droppedUsers, start.Users))
+	log.Info("Archivo limpio       : %s", filteredPath)
+	log.Info("Reporte de filtrado  : %s", filterReport)
+
+	return nil
+}
+
+func loadPairs(path string) (*ratingPairs, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	_, err = reader.Read() // cabecera
+	if err != nil {
+		return nil, fmt.Errorf("leer cabecera: %w", err)
+	}
+
+	p := &ratingPairs{
+		u:       make([]int32, 0, 1_000_000),
+		i:       make([]int32, 0, 1_000_000),
+		userIdx: make(map[int]int32, 200000),
+		itemIdx: make(map[int]int32, 70000),
+	}
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		uid, iid, ok := parseIDs(row)
+		if !ok {
+			continue
+		}
+		u, ok := p.userIdx[uid]
+		if !ok {
+			u = int32(len(p.userIdx))
+			p.userIdx[uid] = u
+		}
+		i, ok := p.itemIdx[iid]
+		if !ok {
+			i = int32(len(p.itemIdx))
+			p.itemIdx[iid] = i
+		}
+		p.u = append(p.u, u)
+		p.i = append(p.i, i)
+	}
+	return p, nil
+}
+
+// parseIDs toma userId y movieId de una fila de ratings.csv.
+func parseIDs(row []string) (uid, iid int, ok bool) {
+	if len(row) < 4 {
+		return 0, 0, false
+	}
+	uid, err1 := strconv.Atoi(strings.TrimSpace(row[0]))
+	iid, err2 := strconv.Atoi(strings.TrimSpace(row[1]))
+	return uid, iid, err1 == nil && err2 == nil
+}
+
+// kcore aplica los umbrales por rondas. En cada ronda se cuentan los ratings
+// vivos de cada usuario/película y se eliminan los que quedan bajo su umbral;
+// con KCore se repite hasta que una ronda no elimina nada (quitar películas
+// puede dejar usuarios bajo el umbral y viceversa). Los usuarios marcados en
+// excluded (nil = ninguno) quedan fuera antes de la primera ronda. Devuelve
+// qué usuarios y películas sobreviven, el estado inicial (con los excluidos),
+// lo que quitó la exclusión (cero sin excluded) y una entrada por ronda.
+func kcore(p *ratingPairs, opt CleanOptions, excluded []bool) (userAlive, itemAlive []bool, start, excl filterRound, rounds []filterRound) {
+	userAlive = make([]bool, len(p.userIdx))
+	itemAlive = make([]bool, len(p.itemIdx))
+	for k := range userAlive {
+		userAlive[k] = true
+	}
+	for k := range itemAlive {
+		itemAlive[k] = true
+	}
+	userCnt := make([]int32, len(userAlive))
+	itemCnt := make([]int32, len(itemAlive))
+
+	// count recuenta sobre las filas vivas y devuelve el estado resultante
+	count := func() filterRound {
+		clear(userCnt)
+		clear(itemCnt)
+		var st filterRound
+		for k := range p.u {
+			u, i := p.u[k], p.i[k]
+			if (p.drop != nil && p.drop[k]) || !userAlive[u] || !itemAlive[i] {
+				continue
+			}
+			userCnt[u]++
+			itemCnt[i]++
+			st.Rows++
+		}
+		for _, c := range userCnt {
+			if c > 0 {
+				st.Users++
+			}
+		}
+		for _, c := range itemCnt {
+			if c > 0 {
+				st.Items++
+			}
+		}
+		return st
+	}
+
+	start = count()
+	prev := start
+	if excluded != nil {
+		for k := range userAlive {
+			userAlive[k] = !excluded[k]
+		}
+		prev = count()
+		excl = filterRound{
+			DroppedRows:  start.Rows - prev.Rows,
+			DroppedUsers: start.Users - prev.Users,
+			DroppedItems: start.Items - prev.Items,
+			Rows:         prev.Rows,
+			Users:        prev.Users,
+			Items:        prev.Items,
+		}
+	}
+	for {
+		dropped := false
+		for i, c := range itemCnt {
+			if itemAlive[i] && int(c) < opt.MinItemRatings {
+				itemAlive[i], dropped = false, true
+			}
+		}
+		for u, c := range userCnt {
+			if userAlive[u] && int(c) < opt.MinUserRatings {
+				userAlive[u], dropped = false, true
+			}
+		}
+		if !dropped {
+			if len(rounds) == 0 {
+				// ronda sin cambios
+				rounds = append(rounds, filterRound{Rows: prev.Rows, Users: prev.Users, Items: prev.Items})
+			}
+			break
+		}
+		cur := count()
+		cur.DroppedRows = prev.Rows - cur.Rows
+		cur.DroppedUsers = prev.Users - cur.Users
+		cur.DroppedItems = prev.Items - cur.Items
+		rounds = append(rounds, cur)
+		prev = cur
+		if !opt.KCore {
+			break
+		}
+	}
+	return userAlive, itemAlive, start, excl, rounds
+}
+
+func writeFilteredRatings(inPath, outPath string, p *ratingPairs, userAlive, itemAlive []bool) (int64, error) {
+	inF, err := os.Open(inPath)
+	if err != nil {
+		return 0, fmt.Errorf("abrir %s: %w", inPath, err)
+	}
+	defer inF.Close()
+
+	outF, err := os.Create(outPath)
+	if err != nil {
+		return 0, fmt.Errorf("crear %s: %w", outPath, err)
+	}
+	defer outF.Close()
+
+	reader := csv.NewReader(bufio.NewReader(inF))
+	reader.FieldsPerRecord = -1
+	writer := csv.NewWriter(bufio.NewWriter(outF))
+	defer writer.Flush()
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, fmt.Errorf("leer cabecera: %w", err)
+	}
+	if len(header) < 4 {
+		return 0, errors.New("cabecera inesperada en ratings.csv (se esperan 4 columnas)")
+	}
+	if err := writer.Write(header); err != nil {
+		return 0, fmt.Errorf("escribir cabecera: %w", err)
+	}
+
+	var keptRows int64
+	for k := int32(0); ; {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		uid, iid, ok := parseIDs(row)
+		if !ok {
+			continue
+		}
+		row = dedupRow(p, k, row)
+		k++
+		if row == nil {
+			continue
+		}
+		u, okU := p.userIdx[uid]
+		i, okI := p.itemIdx[iid]
+		if okU && okI && userAlive[u] && itemAlive[i] {
+			if err := writer.Write(row); err != nil {
+				return keptRows, fmt.Errorf("escribir fila: %w", err)
+			}
+			keptRows++
+		}
+	}
+	return keptRows, nil
+}
+
+func writeFilterReport(path string, opt CleanOptions, dups dupStats, susp suspiciousStats, start, excl filterRound, rounds []filterRound, elapsed time.Duration) error {
+	final := rounds[len(rounds)-1]
+	droppedRows := start.Rows - final.Rows
+	droppedItems := start.Items - final.Items
+	droppedUsers := start.Users - final.Users
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "== FILTRADO MovieLens 25M ==\n\n")
+	fmt.Fprintf(&b, "Criterio aplicado: películas con ≥%d ratings, usuarios con ≥%d ratings.\n", opt.MinItemRatings, opt.MinUserRatings)
+	fmt.Fprintf(&b, "Modo             : %s (%d rondas)\n\n", opt.mode(), len(rounds))
+
+	fmt.Fprintf(&b, "-- Duplicados (userId, movieId), política --dedup=%s --\n", opt.Dedup)
+	fmt.Fprintf(&b, "Filas leídas                 : %d\n", dups.Rows)
+	fmt.Fprintf(&b, "Pares repetidos              : %d\n", dups.Groups)
+	fmt.Fprintf(&b, "Filas descartadas            : %d\n", dups.Removed)
+	fmt.Fprintf(&b, "  contiguas a su par         : %d\n", dups.Contiguous)
+	fmt.Fprintf(&b, "  en otra parte del archivo  : %d\n", dups.Scattered)
+	fmt.Fprintf(&b, "Pares idénticos              : %d (mismo rating y timestamp)\n", dups.Identical)
+	fmt.Fprintf(&b, "Pares con el mismo rating    : %d (distinto timestamp)\n", dups.SameRating)
+	fmt.Fprintf(&b, "Pares con ratings distintos  : %d\n", dups.Conflict)
+	fmt.Fprintf(&b, "Filas tras resolver          : %d\n\n", dups.Rows-dups.Removed)
+
+	so := opt.Suspicious
+	fmt.Fprintf(&b, "-- Perfiles sospechosos (score ≥ %d) --\n", so.Score)
+	fmt.Fprintf(&b, "Usuarios marcados            : %d (%d filas)\n", susp.Users, susp.Rows)
+	fmt.Fprintf(&b, "  entropy  (≤ %.2f bits)      : %d\n", so.Entropy, susp.Reasons["entropy"])
+	fmt.Fprintf(&b, "  variance (desvío ≤ %.2f)    : %d\n", so.Std, susp.Reasons["variance"])
+	fmt.Fprintf(&b, "  burst    (≥ %d en %ds)     : %d\n", so.Burst, so.Window, susp.Reasons["burst"])
+	fmt.Fprintf(&b, "  volume   (≥ %d ratings)   : %d\n", so.Volume, susp.Reasons["volume"])
+	if susp.Excluded {
+		fmt.Fprintf(&b, "Excluidos antes de las rondas (--exclude_suspicious).\n\n")
+	} else {
+		fmt.Fprintf(&b, "Solo se listan; --exclude_suspicious los quita antes de las rondas.\n\n")
+	}
+	// Con --exclude_suspicious "eliminados" incluye lo excluido, que además
+	// va en su propia línea.
+	fmt.Fprintf(&b, "Filas originales     : %d\n", start.Rows)
+	if susp.Excluded {
+		fmt.Fprintf(&b, "Filas excluidas      : %d (%.2f%%, sospechosos)\n", excl.DroppedRows, percent64(excl.DroppedRows, start.Rows))
+	}
+	fmt.Fprintf(&b, "Filas retenidas      : %d\n", final.Rows)
+	fmt.Fprintf(&b, "Filas eliminadas     : %d (%.2f%%)\n\n", droppedRows, percent64(droppedRows, start.Rows))
+
+	fmt.Fprintf(&b, "Películas totales    : %d\n", start.Items)
+	if susp.Excluded {
+		fmt.Fprintf(&b, "Películas excluidas  : %d (solo las calificaban sospechosos)\n", excl.DroppedItems)
+	}
+	fmt.Fprintf(&b, "Películas retenidas  : %d\n", final.Items)
+	fmt.Fprintf(&b, "Películas eliminadas : %d (%.2f%%)\n\n", droppedItems, percent(droppedItems, start.Items))
+
+	fmt.Fprintf(&b, "Usuarios totales     : %d\n", start.Users)
+	if susp.Excluded {
+		fmt.Fprintf(&b, "Usuarios excluidos   : %d (sospechosos)\n", excl.DroppedUsers)
+	}
+	fmt.Fprintf(&b, "Usuarios retenidos   : %d\n", final.Users)
+	fmt.Fprintf(&b, "Usuarios eliminados  : %d (%.2f%%)\n\n", droppedUsers, percent(droppedUsers, start.Users))
+
+	fmt.Fprintf(&b, "-- Rondas (eliminado en la ronda | lo que queda) --\n")
+	fmt.Fprintf(&b, "  %-5s %12s %10s %10s | %12s %10s %10s\n", "ronda", "filas", "usuarios", "películas", "filas", "usuarios", "películas")
+	for k, r := range rounds {
+		fmt.Fprintf(&b, "  %-5d %12d %10d %10d | %12d %10d %10d\n",
+			k+1, r.DroppedRows, r.DroppedUsers, r.DroppedItems, r.Rows, r.Users, r.Items)
+	}
+	fmt.Fprintf(&b, "\n")
+
+	fmt.Fprintf(&b, "Justificación del umbral:\n")
+	fmt.Fprintf(&b, "- Con menos de %d ratings por película, coseno y Pearson son inestables (poco soporte conjunto).\n", opt.MinItemRatings)
+	if opt.MinUserRatings > 0 {
+		fmt.Fprintf(&b, "- Usuarios con menos de %d ratings recibirían recomendaciones a partir de casi ningún dato.\n", opt.MinUserRatings)
+	}
+	if opt.KCore {
+		fmt.Fprintf(&b, "- Quitar películas puede dejar usuarios bajo su umbral (y viceversa): se repite hasta que ninguna ronda elimina nada.\n")
+	}
+	fmt.Fprintf(&b, "- Mantener solo ítems con suficiente señal reduce ruido y costo computacional.\n")
+	fmt.Fprintf(&b, "- Este recorte es para el cómputo de similitudes; la UI puede seguir mostrando metadata completa de movies.\n")
+
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+
+	rep := report.New("clean_filter", opt)
+	rep.Count("rows", start.Rows)
+	rep.Count("kept_rows", final.Rows)
+	rep.Count("items", int64(start.Items))
+	rep.Count("kept_items", int64(final.Items))
+	rep.Count("users", int64(start.Users))
+	rep.Count("kept_users", int64(final.Users))
+	rep.Set("duplicates", dups)
+	rep.Set("suspicious", susp)
+	if susp.Excluded {
+		rep.Set("excluded", excl)
+	}
+	rep.Set("rounds", rounds)
+	rep.Time("total", elapsed)
+	return rep.Write(report.Path(path))
+}
+
+func percent(part, total int) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 100.0 * float64(part) / float64(total)
+}
+func percent64(part, total int64) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 100.0 * float64(part) / float64(total)
+}
+
+// dedup busca pares (usuario, película) repetidos en todo el archivo
+// ordenando los índices de fila, y marca en p qué filas descartar según la
+// política. Solo si hay repetidos relee el CSV para tomar rating y
+// timestamp de esas filas.
+func dedup(path string, p *ratingPairs, policy string) (dupStats, error) {
+	st := dupStats{Rows: int64(len(p.u))}
+	order := make([]int32, len(p.u))
+	for k := range order {
+		order[k] = int32(k)
+	}
+	slices.SortFunc(order, func(a, b int32) int {
+		if p.u[a] != p.u[b] {
+			return int(p.u[a] - p.u[b])
+		}
+		if p.i[a] != p.i[b] {
+			return int(p.i[a] - p.i[b])
+		}
+		return int(a - b)
+	})
+
+	// grupos repetidos: rangos [a,b) de order, con filas en orden de archivo
+	var groups [][2]int
+	need := map[int32]bool{}
+	for a := 0; a < len(order); {
+		b := a + 1
+		for b < len(order) && p.u[order[b]] == p.u[order[a]] && p.i[order[b]] == p.i[order[a]] {
+			b++
+		}
+		if b-a > 1 {
+			groups = append(groups, [2]int{a, b})
+			for _, k := range order[a:b] {
+				need[k] = true
+			}
+		}
+		a = b
+	}
+	if len(groups) == 0 {
+		return st, nil
+	}
+
+	type rt struct {
+		r float64
+		t int64
+	}
+	vals := make(map[int32]rt, len(need))
+	f, err := os.Open(path)
+	if err != nil {
+		return st, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if _, err := reader.Read(); err != nil {
+		return st, fmt.Errorf("leer cabecera: %w", err)
+	}
+	for k := int32(0); ; {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		if _, _, ok := parseIDs(row); !ok {
+			continue
+		}
+		if need[k] {
+			r, _ := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+			t, _ := strconv.ParseInt(strings.TrimSpace(row[3]), 10, 64)
+			vals[k] = rt{r, t}
+		}
+		k++
+	}
+
+	p.drop = make([]bool, len(p.u))
+	p.rating = map[int32]float64{}
+	for _, g := range groups {
+		rows := order[g[0]:g[1]]
+		st.Groups++
+		st.Removed += int64(len(rows) - 1)
+		sameR, sameT := true, true
+		for n, k := range rows[1:] {
+			if k == rows[n]+1 {
+				st.Contiguous++
+			} else {
+				st.Scattered++
+			}
+			sameR = sameR && vals[k].r == vals[rows[0]].r
+			sameT = sameT && vals[k].t == vals[rows[0]].t
+		}
+		switch {
+		case !sameR:
+			st.Conflict++
+		case sameT:
+			st.Identical++
+		default:
+			st.SameRating++
+		}
+
+		keep := rows[0]
+		switch policy {
+		case "last":
+			keep = rows[len(rows)-1]
+		case "latest_timestamp":
+			for _, k := range rows {
+				if vals[k].t >= vals[keep].t {
+					keep = k
+				}
+			}
+		case "mean":
+			if sameR {
+				break // el rating ya es el promedio
+			}
+			var sum float64
+			half := true
+			for _, k := range rows {
+				sum += vals[k].r
+				half = half && validStep(vals[k].r)
+			}
+			mean := sum / float64(len(rows))
+			if half {
+				// sobre medias estrellas el promedio vuelve a la escala
+				// (4 y 4.5 -> 4.25 -> 4.5) para que remap pueda cuantizar
+				mean = mathRound(mean*2) / 2
+			}
+			p.rating[keep] = mean
+		}
+		for _, k := range rows {
+			p.drop[k] = k != keep
+		}
+	}
+	return st, nil
+}
+
+// dedupRow aplica la resolución de duplicados a la fila k: nil si se
+// descarta, o la fila con el rating promedio (--dedup=mean).
+func dedupRow(p *ratingPairs, k int32, row []string) []string {
+	if p.drop == nil {
+		return row
+	}
+	if p.drop[k] {
+		return nil
+	}
+	if r, ok := p.rating[k]; ok {
+		row[2] = strconv.FormatFloat(r, 'f', -1, 64)
+	}
+	return row
+}
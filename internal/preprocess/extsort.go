@@ -0,0 +1,236 @@
+package preprocess
+
+/*
+ORDENAMIENTO EXTERNO de triplets (remap / normalize con --mem_limit)
+
+Los triplets se acumulan en un buffer de a lo sumo mem_limit bytes; cuando
+se llena se ordena y se vuelca a un "run" binario en <artifacts>/tmp. Al
+final los runs se mezclan (k-way merge con un heap) y se entregan en orden.
+Si el buffer nunca se llenó (o mem_limit = 0) no se toca el disco.
+
+Formato de un run: registros de 24 bytes little-endian
+  u int32 | i int32 | r float64 | t int64
+*/
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+)
+
+const (
+	tripletBytes = 32 // tamaño de Triplet en memoria (para el presupuesto)
+	runRecord    = 24 // tamaño de un registro en disco
+	minRunLen    = 1 << 12
+)
+
+func byUserItem(a, b Triplet) bool {
+	if a.U == b.U {
+		return a.I < b.I
+	}
+	return a.U < b.U
+}
+
+func byItemUser(a, b Triplet) bool {
+	if a.I == b.I {
+		return a.U < b.U
+	}
+	return a.I < b.I
+}
+
+type tripletSorter struct {
+	dir    string
+	less   func(a, b Triplet) bool
+	runLen int // triplets por run (0 = sin límite, todo en memoria)
+	buf    []Triplet
+	runs   []string
+}
+
+// newTripletSorter crea un ordenador que usa como mucho memLimit bytes de
+// buffer (0 = sin límite) y vuelca los runs en dir.
+func newTripletSorter(dir string, memLimit int64, less func(a, b Triplet) bool) *tripletSorter {
+	s := &tripletSorter{dir: dir, less: less}
+	if memLimit > 0 {
+		s.runLen = max(int(memLimit/tripletBytes), minRunLen)
+		s.buf = make([]Triplet, 0, s.runLen)
+	}
+	return s
+}
+
+func (s *tripletSorter) Add(t Triplet) error {
+	s.buf = append(s.buf, t)
+	if s.runLen > 0 && len(s.buf) >= s.runLen {
+		return s.spill()
+	}
+	return nil
+}
+
+// Runs es la cantidad de runs volcados a disco hasta ahora.
+func (s *tripletSorter) Runs() int { return len(s.runs) }
+
+func (s *tripletSorter) sortBuf() {
+	sort.Slice(s.buf, func(a, b int) bool { return s.less(s.buf[a], s.buf[b]) })
+}
+
+func (s *tripletSorter) spill() error {
+	s.sortBuf()
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(s.dir, "run-*.bin")
+	if err != nil {
+		return err
+	}
+	s.runs = append(s.runs, f.Name())
+	w := bufio.NewWriterSize(f, 1<<20)
+	var rec [runRecord]byte
+	for _, t := range s.buf {
+		encodeTriplet(rec[:], t)
+		if _, err := w.Write(rec[:]); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	s.buf = s.buf[:0]
+	return f.Close()
+}
+
+// Each entrega todos los triplets en orden. Solo puede llamarse una vez.
+func (s *tripletSorter) Each(fn func(Triplet) error) error {
+	if len(s.runs) == 0 {
+		s.sortBuf()
+		for _, t := range s.buf {
+			if err := fn(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(s.buf) > 0 {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+	s.buf = nil // el merge solo necesita un registro por run
+
+	h := &mergeHeap{less: s.less}
+	for _, path := range s.runs {
+		f, err := os.Open(path)
+		if err != nil {
+			h.close()
+			return err
+		}
+		r := &runReader{f: f, r: bufio.NewReaderSize(f, 1<<16)}
+		h.readers = append(h.readers, r)
+		ok, err := r.next()
+		if err != nil {
+			h.close()
+			return err
+		}
+		if ok {
+			h.items = append(h.items, r)
+		}
+	}
+	defer h.close()
+	heap.Init(h)
+	for h.Len() > 0 {
+		r := h.items[0]
+		if err := fn(r.cur); err != nil {
+			return err
+		}
+		ok, err := r.next()
+		if err != nil {
+			return err
+		}
+		if ok {
+			heap.Fix(h, 0)
+		} else {
+			heap.Pop(h)
+		}
+	}
+	return nil
+}
+
+// Close borra los runs (y dir si quedó vacío).
+func (s *tripletSorter) Close() error {
+	var errs []error
+	for _, path := range s.runs {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	if len(s.runs) > 0 {
+		_ = os.Remove(s.dir) // falla si otro ordenador sigue usándolo
+	}
+	s.runs, s.buf = nil, nil
+	return errors.Join(errs...)
+}
+
+func encodeTriplet(b []byte, t Triplet) {
+	binary.LittleEndian.PutUint32(b[0:], uint32(t.U))
+	binary.LittleEndian.PutUint32(b[4:], uint32(t.I))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(t.R))
+	binary.LittleEndian.PutUint64(b[16:], uint64(t.T))
+}
+
+func decodeTriplet(b []byte) Triplet {
+	return Triplet{
+		U: int(int32(binary.LittleEndian.Uint32(b[0:]))),
+		I: int(int32(binary.LittleEndian.Uint32(b[4:]))),
+		R: math.Float64frombits(binary.LittleEndian.Uint64(b[8:])),
+		T: int64(binary.LittleEndian.Uint64(b[16:])),
+	}
+}
+
+// runReader lee un run registro a registro.
+type runReader struct {
+	f   *os.File
+	r   *bufio.Reader
+	rec [runRecord]byte
+	cur Triplet
+}
+
+func (r *runReader) next() (bool, error) {
+	_, err := io.ReadFull(r.r, r.rec[:])
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("leer %s: %w", r.f.Name(), err)
+	}
+	r.cur = decodeTriplet(r.rec[:])
+	return true, nil
+}
+
+// mergeHeap ordena los runs por su registro actual.
+type mergeHeap struct {
+	items   []*runReader
+	readers []*runReader // todos, para cerrarlos al final
+	less    func(a, b Triplet) bool
+}
+
+func (h *mergeHeap) Len() int           { return len(h.items) }
+func (h *mergeHeap) Less(a, b int) bool { return h.less(h.items[a].cur, h.items[b].cur) }
+func (h *mergeHeap) Swap(a, b int)      { h.items[a], h.items[b] = h.items[b], h.items[a] }
+func (h *mergeHeap) Push(x any)         { h.items = append(h.items, x.(*runReader)) }
+func (h *mergeHeap) Pop() any {
+	r := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return r
+}
+
+func (h *mergeHeap) close() {
+	for _, r := range h.readers {
+		r.f.Close()
+	}
+}
@@ -0,0 +1,401 @@
+package preprocess
+
+/*
+FEATURES DE CONTENIDO (tags) → CSR ítem×feature
+
+Entradas (todas opcionales en <data>; se procesa lo que exista):
+  - genome-scores.csv (movieId,tagId,relevance)   relevancia 0–1 del Tag Genome
+  - genome-tags.csv   (tagId,tag)                 nombres de los tags del genome
+  - tags.csv          (userId,movieId,tag,timestamp) tags libres de usuarios
+  - <artifacts>/index/item_map.csv                filas = iIdx
+
+Salidas (mismo layout binario que normalize, ver internal/csr):
+  - <artifacts>/features/genome_csr/   valor = relevancia (> 0 y ≥ --min_relevance)
+  - <artifacts>/features/genome_vocab.csv  (col,tagId,tag)
+  - <artifacts>/features/tags_csr/     valor = nº de usuarios distintos que
+                                        aplicaron el tag a la película
+  - <artifacts>/features/tag_vocab.csv     (col,tag,users,items)
+  - <artifacts>/features_report.txt (+ .json, ver internal/report)
+
+Notas:
+  - Filas = iIdx (mismo orden que matrix_item_csr); películas sin iIdx
+    (filtradas por clean) se descartan. meta.json lleva "features" = nº de
+    columnas, así csr.Open sabe que las columnas no son usuarios.
+  - Los tags libres se normalizan (minúsculas, espacios colapsados) y solo
+    entran al vocabulario los usados por ≥ --min_tag_users usuarios.
+  - Dentro de cada fila las columnas quedan en orden creciente.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pc3/internal/csr"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+)
+
+// FeaturesOptions son los umbrales de la etapa.
+type FeaturesOptions struct {
+	MinRelevance float64 `json:"min_relevance"` // genome: descartar relevancias menores
+	MinTagUsers  int     `json:"min_tag_users"` // tags libres: usuarios distintos mínimos por tag
+}
+
+// featureStats resume una matriz generada para el reporte.
+type featureStats struct {
+	name              string
+	rows, cols        int
+	itemsWithFeatures int
+	nnz               int
+	skippedRows       int // filas de entrada sin iIdx o inválidas
+}
+
+// Features genera los CSR de contenido que tengan entrada disponible.
+func Features(l layout.Layout, opt FeaturesOptions) error {
+	itemIdx, nItems, err := readIndexMap(l.ItemMap())
+	if err != nil {
+		return err
+	}
+	if nItems == 0 {
+		return fmt.Errorf("%s vacío o inexistente (correr remap antes)", l.ItemMap())
+	}
+
+	var stats []featureStats
+	var notes []string
+	if exists(l.GenomeScores()) {
+		st, err := genomeFeatures(l, itemIdx, nItems, opt)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, st)
+	} else {
+		notes = append(notes, fmt.Sprintf("no existe %s: se omite genome", l.GenomeScores()))
+	}
+	if exists(l.Tags()) {
+		st, err := tagFeatures(l, itemIdx, nItems, opt)
+		if err != nil {
+			return err
+		}
+		stats = append(stats, st)
+	} else {
+		notes = append(notes, fmt.Sprintf("no existe %s: se omiten los tags de usuarios", l.Tags()))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "== FEATURES DE CONTENIDO ==\n\n")
+	fmt.Fprintf(&b, "min_relevance (genome) : %g\n", opt.MinRelevance)
+	fmt.Fprintf(&b, "min_tag_users (tags)   : %d\n", opt.MinTagUsers)
+	fmt.Fprintf(&b, "Ítems (filas = iIdx)   : %d\n\n", nItems)
+	for _, n := range notes {
+		fmt.Fprintf(&b, "AVISO: %s\n", n)
+	}
+	if len(notes) > 0 {
+		fmt.Fprintf(&b, "\n")
+	}
+	for _, st := range stats {
+		fmt.Fprintf(&b, "-- %s --\n", st.name)
+		fmt.Fprintf(&b, "Features (columnas)    : %d\n", st.cols)
+		fmt.Fprintf(&b, "Ítems con features     : %d (%.2f%%)\n", st.itemsWithFeatures, percent(st.itemsWithFeatures, st.rows))
+		fmt.Fprintf(&b, "NNZ                    : %d\n", st.nnz)
+		fmt.Fprintf(&b, "Densidad               : %.4f%%\n", 100*float64(st.nnz)/float64(max(1, st.rows*st.cols)))
+		fmt.Fprintf(&b, "Filas descartadas      : %d (sin iIdx, bajo umbral o inválidas)\n\n", st.skippedRows)
+	}
+	if err := os.WriteFile(l.FeaturesReport(), []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	rep := report.New("features", opt)
+	rep.Count("items", int64(nItems))
+	for _, st := range stats {
+		rep.Count(st.name+"_features", int64(st.cols))
+		rep.Count(st.name+"_items_with_features", int64(st.itemsWithFeatures))
+		rep.Count(st.name+"_nnz", int64(st.nnz))
+		rep.Count(st.name+"_skipped_rows", int64(st.skippedRows))
+		rep.Metric(st.name+"_density", float64(st.nnz)/float64(max(1, st.rows*st.cols)))
+	}
+	if exists(l.GenomeScores()) {
+		rep.Output("genome_csr", l.GenomeCSR())
+		rep.Output("genome_vocab", l.GenomeVocab())
+	}
+	if exists(l.Tags()) {
+		rep.Output("tags_csr", l.TagsCSR())
+		rep.Output("tag_vocab", l.TagVocab())
+	}
+	if len(notes) > 0 {
+		rep.Set("warnings", notes)
+	}
+	if err := rep.Write(report.Path(l.FeaturesReport())); err != nil {
+		return err
+	}
+
+	for _, st := range stats {
+		fmt.Printf("[OK] FEATURES %s: I=%d F=%d NNZ=%d\n", st.name, st.rows, st.cols, st.nnz)
+	}
+	if len(stats) == 0 {
+		fmt.Printf("[OK] FEATURES: sin tags.csv ni genome-scores.csv en %s\n", l.Data)
+	}
+	return nil
+}
+
+// genomeFeatures arma genome_csr: columna = posición del tagId en el
+// vocabulario (tagIds ordenados), valor = relevancia.
+func genomeFeatures(l layout.Layout, itemIdx map[int]int, nItems int, opt FeaturesOptions) (featureStats, error) {
+	st := featureStats{name: "genome", rows: nItems}
+
+	names, err := readGenomeTags(l.GenomeTags())
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return st, err
+	}
+
+	var rows, tagIDs []int32
+	var vals []float32
+	err = readCSV(l.GenomeScores(), func(rec []string) {
+		if len(rec) < 3 {
+			st.skippedRows++
+			return
+		}
+		id, err1 := strconv.Atoi(rec[0])
+		tag, err2 := strconv.Atoi(rec[1])
+		rel, err3 := strconv.ParseFloat(rec[2], 64)
+		i, ok := itemIdx[id]
+		if err1 != nil || err2 != nil || err3 != nil || !ok || rel < opt.MinRelevance || rel == 0 {
+			st.skippedRows++
+			return
+		}
+		rows = append(rows, int32(i))
+		tagIDs = append(tagIDs, int32(tag))
+		vals = append(vals, float32(rel))
+	})
+	if err != nil {
+		return st, err
+	}
+
+	// vocabulario: todos los tagIds conocidos (nombres + los que aparecen)
+	seen := map[int32]bool{}
+	for id := range names {
+		seen[id] = true
+	}
+	for _, id := range tagIDs {
+		seen[id] = true
+	}
+	vocab := make([]int32, 0, len(seen))
+	for id := range seen {
+		vocab = append(vocab, id)
+	}
+	sort.Slice(vocab, func(a, b int) bool { return vocab[a] < vocab[b] })
+	col := make(map[int32]int32, len(vocab))
+	for c, id := range vocab {
+		col[id] = int32(c)
+	}
+	cols := make([]int32, len(tagIDs))
+	for k, id := range tagIDs {
+		cols[k] = col[id]
+	}
+
+	if err := writeVocab(l.GenomeVocab(), []string{"col", "tagId", "tag"}, len(vocab), func(c int) []string {
+		return []string{strconv.Itoa(c), strconv.Itoa(int(vocab[c])), names[vocab[c]]}
+	}); err != nil {
+		return st, err
+	}
+	st.cols = len(vocab)
+	return st, writeFeatureCSR(l.GenomeCSR(), nItems, len(vocab), rows, cols, vals, &st)
+}
+
+// tagFeatures arma tags_csr: valor = usuarios distintos que aplicaron el
+// tag (normalizado) a la película.
+func tagFeatures(l layout.Layout, itemIdx map[int]int, nItems int, opt FeaturesOptions) (featureStats, error) {
+	st := featureStats{name: "tags", rows: nItems}
+
+	type key struct {
+		item int
+		tag  string
+	}
+	type use struct {
+		user int
+		key
+	}
+	applied := map[use]bool{} // (usuario, ítem, tag) distintos
+	itemTag := map[key]int{}  // usuarios por (ítem, tag)
+	tagUsers := map[string]map[int]bool{}
+	err := readCSV(l.Tags(), func(rec []string) {
+		if len(rec) < 3 {
+			st.skippedRows++
+			return
+		}
+		uid, err1 := strconv.Atoi(strings.TrimSpace(rec[0]))
+		id, err2 := strconv.Atoi(strings.TrimSpace(rec[1]))
+		tag := normalizeTag(rec[2])
+		i, ok := itemIdx[id]
+		if err1 != nil || err2 != nil || !ok || tag == "" {
+			st.skippedRows++
+			return
+		}
+		u := use{uid, key{i, tag}}
+		if applied[u] {
+			return
+		}
+		applied[u] = true
+		itemTag[u.key]++
+		if tagUsers[tag] == nil {
+			tagUsers[tag] = map[int]bool{}
+		}
+		tagUsers[tag][uid] = true
+	})
+	if err != nil {
+		return st, err
+	}
+
+	// vocabulario: tags con suficientes usuarios, en orden alfabético
+	var vocab []string
+	for tag, us := range tagUsers {
+		if len(us) >= opt.MinTagUsers {
+			vocab = append(vocab, tag)
+		}
+	}
+	sort.Strings(vocab)
+	col := make(map[string]int32, len(vocab))
+	for c, tag := range vocab {
+		col[tag] = int32(c)
+	}
+
+	var rows, cols []int32
+	var vals []float32
+	tagItems := map[string]int{}
+	for k, n := range itemTag {
+		c, ok := col[k.tag]
+		if !ok {
+			st.skippedRows += n
+			continue
+		}
+		rows = append(rows, int32(k.item))
+		cols = append(cols, c)
+		vals = append(vals, float32(n))
+		tagItems[k.tag]++
+	}
+
+	if err := writeVocab(l.TagVocab(), []string{"col", "tag", "users", "items"}, len(vocab), func(c int) []string {
+		t := vocab[c]
+		return []string{strconv.Itoa(c), t, strconv.Itoa(len(tagUsers[t])), strconv.Itoa(tagItems[t])}
+	}); err != nil {
+		return st, err
+	}
+	st.cols = len(vocab)
+	return st, writeFeatureCSR(l.TagsCSR(), nItems, len(vocab), rows, cols, vals, &st)
+}
+
+// normalizeTag pasa a minúsculas y colapsa espacios ("  Sci-Fi " → "sci-fi").
+func normalizeTag(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// writeFeatureCSR agrupa (fila, col, valor) por fila con columnas crecientes
+// y escribe el CSR ítem×feature.
+func writeFeatureCSR(dir string, nRows, nCols int, rows, cols []int32, vals []float32, st *featureStats) error {
+	order := make([]int, len(rows))
+	for k := range order {
+		order[k] = k
+	}
+	sort.Slice(order, func(a, b int) bool {
+		ra, rb := rows[order[a]], rows[order[b]]
+		if ra != rb {
+			return ra < rb
+		}
+		return cols[order[a]] < cols[order[b]]
+	})
+
+	indptr := make([]int64, nRows+1)
+	indices := make([]int32, len(order))
+	data := make([]float32, len(order))
+	for p, k := range order {
+		indptr[rows[k]+1]++
+		indices[p] = cols[k]
+		data[p] = vals[k]
+	}
+	for r := 0; r < nRows; r++ {
+		if indptr[r+1] > 0 {
+			st.itemsWithFeatures++
+		}
+		indptr[r+1] += indptr[r]
+	}
+	st.nnz = len(order)
+
+	m, err := csr.New(csr.Meta{Items: nRows, Features: nCols, Axis: csr.AxisItem}, indptr, indices, data)
+	if err != nil {
+		return err
+	}
+	if err := csr.Write(dir, m); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", dir, err)
+	}
+	return nil
+}
+
+func readGenomeTags(path string) (map[int32]string, error) {
+	out := map[int32]string{}
+	err := readCSV(path, func(rec []string) {
+		if len(rec) < 2 {
+			return
+		}
+		if id, err := strconv.Atoi(strings.TrimSpace(rec[0])); err == nil {
+			out[int32(id)] = strings.TrimSpace(rec[1])
+		}
+	})
+	return out, err
+}
+
+// readCSV recorre un CSV con cabecera (comillas incluidas) fila a fila;
+// las filas mal formadas se saltean.
+func readCSV(path string, fn func(rec []string)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	r.FieldsPerRecord = -1
+	r.ReuseRecord = true
+	r.LazyQuotes = true
+	if _, err := r.Read(); err != nil && err != io.EOF {
+		return fmt.Errorf("leer cabecera de %s: %w", path, err)
+	}
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			continue
+		}
+		fn(rec)
+	}
+}
+
+func writeVocab(path string, header []string, n int, row func(c int) []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write(header)
+	for c := 0; c < n; c++ {
+		_ = w.Write(row(c))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,194 @@
+package preprocess
+
+/*
+FEEDBACK IMPLÍCITO (--axis=implicit)
+
+Los ratings explícitos se convierten en pesos de interacción:
+
+  --implicit_mode=binary      w = 1 si r ≥ --threshold; las filas bajo el
+                              umbral se descartan (no son interacciones
+                              positivas)
+  --implicit_mode=confidence  w = 1 + α·r para cada rating observado (Hu,
+                              Koren y Volinsky, 2008), α = --alpha
+
+Salidas:
+  - <artifacts>/implicit.json            // modo, umbral, α y conteos
+  - <artifacts>/matrix_implicit_csr/     // filas = usuarios, valor = w
+  - <artifacts>/matrix_implicit_csc/     // filas = ítems,    valor = w
+  - <artifacts>/normalize_implicit_report.txt (+ .json)
+
+meta.json lleva "variant": binary | confidence. sim --input=implicit toma
+la orientación que pide --mode y recommend --implicit evalúa solo con
+métricas de ranking (ver internal/recommend/implicit.go). El flag es
+--implicit_mode y no --implicit porque en pc3 pipeline los flags con el
+mismo nombre se comparten y el de recommend es un bool; el pipeline activa
+recommend --implicit solo cuando sim usa --input=implicit.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"pc3/internal/csr"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+)
+
+// Valores de --axis y --implicit_mode.
+const (
+	AxisImplicit       = "implicit"
+	ImplicitBinary     = "binary"
+	ImplicitConfidence = "confidence"
+)
+
+// Implicit es el contenido de implicit.json.
+type Implicit struct {
+	Mode      string  `json:"mode"`      // binary | confidence
+	Threshold float64 `json:"threshold"` // binary: rating mínimo positivo
+	Alpha     float64 `json:"alpha"`     // confidence: w = 1 + α·r
+	Users     int     `json:"users"`
+	Items     int     `json:"items"`
+	Ratings   int     `json:"ratings"` // ratings leídos
+	NNZ       int     `json:"nnz"`     // interacciones escritas
+	CSR       string  `json:"csr"`
+	CSC       string  `json:"csc"`
+}
+
+// Weight devuelve el peso de r y si la interacción se conserva.
+func (m Implicit) Weight(r float64) (float64, bool) {
+	if m.Mode == ImplicitConfidence {
+		return 1 + m.Alpha*r, true
+	}
+	return 1, r >= m.Threshold
+}
+
+// LoadImplicit lee implicit.json.
+func LoadImplicit(path string) (Implicit, error) {
+	var m Implicit
+	js, err := os.ReadFile(path)
+	if err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal(js, &m); err != nil {
+		return m, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+func normalizeImplicit(l layout.Layout, opt NormalizeOptions) error {
+	m := Implicit{Mode: opt.ImplicitMode, Threshold: opt.Threshold, Alpha: opt.Alpha}
+	switch m.Mode {
+	case ImplicitBinary:
+	case ImplicitConfidence:
+		if m.Alpha <= 0 {
+			return fmt.Errorf("--alpha debe ser > 0 (recibido %g)", m.Alpha)
+		}
+	default:
+		return fmt.Errorf("--implicit_mode debe ser binary o confidence (recibido %q)", m.Mode)
+	}
+	t0 := time.Now()
+	rep := report.New("normalize", opt)
+
+	// CSR (u,i) y CSC (i,u): dos órdenes, el presupuesto se reparte
+	byUser := newTripletSorter(l.TmpDir(), opt.MemLimit/2, byUserItem)
+	defer byUser.Close()
+	byItem := newTripletSorter(l.TmpDir(), opt.MemLimit/2, byItemUser)
+	defer byItem.Close()
+
+	// --- PASO 1: pesos y orden; U/I cuentan también las filas descartadas
+	// para que los índices sigan alineados con index/*.csv ---
+	src, err := eachTriplet(l, opt.TS, func(tr Triplet) error {
+		m.Ratings++
+		m.Users = max(m.Users, tr.U+1)
+		m.Items = max(m.Items, tr.I+1)
+		w, keep := m.Weight(tr.R)
+		if !keep {
+			return nil
+		}
+		m.NNZ++
+		tr.R = w
+		if err := byUser.Add(tr); err != nil {
+			return fmt.Errorf("volcando run temporal: %w", err)
+		}
+		if err := byItem.Add(tr); err != nil {
+			return fmt.Errorf("volcando run temporal: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if m.NNZ == 0 {
+		return fmt.Errorf("%s: ningún rating ≥ %g (--threshold)", src, m.Threshold)
+	}
+	rep.Time("read", time.Since(t0))
+
+	// --- PASO 2: CSR y CSC con los pesos ---
+	t1 := time.Now()
+	m.CSR, m.CSC = l.ImplicitDir(csr.AxisUser), l.ImplicitDir(csr.AxisItem)
+	if err := writeNormalized(byUser, opt.TS, csrSink{
+		dir:  m.CSR,
+		meta: csr.Meta{Users: m.Users, Items: m.Items, Axis: csr.AxisUser, Variant: m.Mode},
+		cell: func(t Triplet) (int, int32, float64) { return t.U, int32(t.I), t.R },
+	}); err != nil {
+		return err
+	}
+	if err := writeNormalized(byItem, opt.TS, csrSink{
+		dir:  m.CSC,
+		meta: csr.Meta{Users: m.Users, Items: m.Items, Axis: csr.AxisItem, Variant: m.Mode},
+		cell: func(t Triplet) (int, int32, float64) { return t.I, int32(t.U), t.R },
+	}); err != nil {
+		return err
+	}
+	js, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(l.Implicit(), append(js, '\n'), 0o644); err != nil {
+		return err
+	}
+	rep.Time("write", time.Since(t1))
+	fmt.Printf("[OK] IMPLICIT (%s) -> U=%d I=%d NNZ=%d de %d ratings  out=%s, %s\n",
+		m.Mode, m.Users, m.Items, m.NNZ, m.Ratings, m.CSR, m.CSC)
+
+	rep.Time("total", time.Since(t0))
+	rep.Count("users", int64(m.Users))
+	rep.Count("items", int64(m.Items))
+	rep.Count("ratings", int64(m.Ratings))
+	rep.Count("nnz", int64(m.NNZ))
+	rep.Count("dropped", int64(m.Ratings-m.NNZ))
+	rep.Input("triplets", src)
+	rep.Output("implicit", l.Implicit())
+	rep.Output("implicit_csr", m.CSR)
+	rep.Output("implicit_csc", m.CSC)
+	return writeImplicitReport(l.NormalizeReport(AxisImplicit), m, rep)
+}
+
+func writeImplicitReport(path string, m Implicit, rep *report.Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "== NORMALIZE (implicit %s) ==\n", m.Mode)
+	fmt.Fprintf(&b, "Entrada            : %s\n", rep.Inputs["triplets"])
+	if m.Mode == ImplicitBinary {
+		fmt.Fprintf(&b, "Peso               : 1 si rating ≥ %g\n", m.Threshold)
+	} else {
+		fmt.Fprintf(&b, "Peso               : 1 + %g·rating\n", m.Alpha)
+	}
+	fmt.Fprintf(&b, "Usuarios (U)       : %d\n", m.Users)
+	fmt.Fprintf(&b, "Items (I)          : %d\n", m.Items)
+	fmt.Fprintf(&b, "Ratings leídos     : %d\n", m.Ratings)
+	fmt.Fprintf(&b, "Interacciones (NNZ): %d (%d descartadas)\n\n", m.NNZ, m.Ratings-m.NNZ)
+	fmt.Fprintf(&b, "Tiempos:\n")
+	for _, ph := range []struct{ key, label string }{
+		{"read", "Leer triplets"}, {"write", "Escribir"}, {"total", "TOTAL"},
+	} {
+		fmt.Fprintf(&b, "  %-14s: %.3fs\n", ph.label, rep.Timings[ph.key])
+	}
+	fmt.Fprintf(&b, "\nSalidas:\n  %s\n  %s\n  %s\n", rep.Outputs["implicit"], m.CSR, m.CSC)
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return rep.Write(report.Path(path))
+}
@@ -0,0 +1,173 @@
+package preprocess
+
+/*
+METADATA DE PELÍCULAS (movies.csv → iIdx)
+
+Entradas:
+  - <data>/movies.csv              (movieId,title,genres; títulos con comas van entre comillas)
+  - <artifacts>/index/item_map.csv (movieId,iIdx; salida de remap)
+
+Salidas:
+  - <artifacts>/index/items.csv    (iIdx,movieId,title,year,genres; ver internal/catalog)
+  - <artifacts>/movies_report.txt  // cobertura y géneros más frecuentes (+ .json)
+
+El año se separa del título ("Pulp Fiction (1994)" → "Pulp Fiction", 1994).
+Solo se guardan los ítems del item_map: las películas filtradas por clean
+no tienen iIdx. Sin movies.csv se escribe igual items.csv (sin títulos) para
+que las etapas siguientes no dependan de él.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"pc3/internal/catalog"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+)
+
+// Movies une la metadata de movies.csv a los iIdx de item_map.csv.
+func Movies(l layout.Layout) error {
+	itemIdx, _, err := readIndexMap(l.ItemMap())
+	if err != nil {
+		return err
+	}
+	if len(itemIdx) == 0 {
+		return fmt.Errorf("%s vacío o inexistente (correr remap antes)", l.ItemMap())
+	}
+
+	meta, rows, bad, err := readMovies(l.Movies())
+	missingFile := errors.Is(err, fs.ErrNotExist)
+	if err != nil && !missingFile {
+		return err
+	}
+
+	items := make([]catalog.Item, 0, len(itemIdx))
+	var withMeta, noYear, noGenres int
+	genreCount := map[string]int{}
+	for id, idx := range itemIdx {
+		it, ok := meta[id]
+		it.IIdx, it.MovieID = idx, id
+		if ok {
+			withMeta++
+			if it.Year == 0 {
+				noYear++
+			}
+			if len(it.Genres) == 0 {
+				noGenres++
+			}
+			for _, g := range it.Genres {
+				genreCount[g]++
+			}
+		}
+		items = append(items, it)
+	}
+	cat := catalog.New(items)
+	if err := catalog.Write(l.Items(), cat); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", l.Items(), err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "== METADATA DE PELÍCULAS ==\n\n")
+	if missingFile {
+		fmt.Fprintf(&b, "AVISO: no existe %s; items.csv queda sin títulos.\n\n", l.Movies())
+	}
+	fmt.Fprintf(&b, "Filas en movies.csv          : %d (inválidas: %d)\n", rows, bad)
+	fmt.Fprintf(&b, "Ítems en item_map            : %d\n", len(itemIdx))
+	fmt.Fprintf(&b, "Ítems con metadata           : %d (%.2f%%)\n", withMeta, percent(withMeta, len(itemIdx)))
+	fmt.Fprintf(&b, "Ítems sin fila en movies.csv : %d\n", len(itemIdx)-withMeta)
+	fmt.Fprintf(&b, "Películas sin iIdx (filtradas): %d\n", max(0, len(meta)-withMeta))
+	fmt.Fprintf(&b, "Títulos sin año              : %d\n", noYear)
+	fmt.Fprintf(&b, "Sin géneros                  : %d\n\n", noGenres)
+
+	type kv struct {
+		g string
+		n int
+	}
+	var gs []kv
+	for g, n := range genreCount {
+		gs = append(gs, kv{g, n})
+	}
+	sort.Slice(gs, func(a, b int) bool {
+		if gs[a].n == gs[b].n {
+			return gs[a].g < gs[b].g
+		}
+		return gs[a].n > gs[b].n
+	})
+	fmt.Fprintf(&b, "Géneros (ítems con iIdx):\n")
+	for _, x := range gs {
+		fmt.Fprintf(&b, "  %-20s %d\n", x.g, x.n)
+	}
+	fmt.Fprintf(&b, "\nSalida: %s\n", l.Items())
+	if err := os.WriteFile(l.MoviesReport(), []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	rep := report.New("movies", nil)
+	rep.Count("movie_rows", int64(rows))
+	rep.Count("invalid_rows", int64(bad))
+	rep.Count("items", int64(len(itemIdx)))
+	rep.Count("items_with_metadata", int64(withMeta))
+	rep.Count("movies_without_iidx", int64(max(0, len(meta)-withMeta)))
+	rep.Count("titles_without_year", int64(noYear))
+	rep.Count("without_genres", int64(noGenres))
+	rep.Metric("coverage", float64(withMeta)/float64(max(1, len(itemIdx))))
+	rep.Set("genres", genreCount)
+	rep.Set("movies_csv_missing", missingFile)
+	rep.Output("items", l.Items())
+	if err := rep.Write(report.Path(l.MoviesReport())); err != nil {
+		return err
+	}
+
+	fmt.Printf("[OK] MOVIES: %d/%d ítems con metadata\n  -> %s\n", withMeta, len(itemIdx), l.Items())
+	return nil
+}
+
+// readMovies parsea movies.csv (encoding/csv maneja las comillas y comas de
+// los títulos). Devuelve la metadata por movieId, las filas leídas y las
+// inválidas.
+func readMovies(path string) (map[int]catalog.Item, int, int, error) {
+	out := map[int]catalog.Item{}
+	f, err := os.Open(path)
+	if err != nil {
+		return out, 0, 0, err
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReader(f))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if _, err := r.Read(); err != nil {
+		return out, 0, 0, fmt.Errorf("leer cabecera de %s: %w", path, err)
+	}
+	rows, bad := 0, 0
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		rows++
+		if err != nil || len(rec) < 2 {
+			bad++
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(rec[0]))
+		if err != nil {
+			bad++
+			continue
+		}
+		title, year := catalog.ParseTitle(rec[1])
+		var genres []string
+		if len(rec) > 2 {
+			genres = catalog.ParseGenres(rec[2])
+		}
+		out[id] = catalog.Item{MovieID: id, Title: title, Year: year, Genres: genres}
+	}
+	return out, rows, bad, nil
+}
@@ -0,0 +1,235 @@
+package preprocess
+
+/*
+NORMALIZACIÓN POR FILA (--norm, para --axis=user|item|both)
+
+  center      r' = r - μ            (histórico)
+  zscore      r' = (r - μ) / σ      σ = desvío poblacional de la fila; r' = 0 si σ = 0
+  percentile  r' = P(r) - 0.5       P = percentil de r entre los ratings de la fila,
+                                    por rango medio: (menores + iguales/2) / n
+
+El desvío corrige a quien puntúa todo 4–5 frente a quien usa la escala
+completa; el percentil además ignora la forma de la distribución. En ambos
+casos r' queda centrado en 0 como con center, así Pearson/coseno sobre el CSR
+se usan igual.
+
+Salidas adicionales junto a <axis>_means.csv:
+  zscore     -> <axis>_stds.csv  (idx,std)
+  percentile -> <axis>_ranks.csv (idx,rating,pct), una fila por rating distinto
+
+recommend --norm desnormaliza la predicción user-based con estas tablas:
+μ_u + σ_u·z, o el rating de u en el percentil predicho (RankTable.Rating).
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"pc3/internal/layout"
+)
+
+// Valores de --norm.
+const (
+	NormCenter     = "center"
+	NormZScore     = "zscore"
+	NormPercentile = "percentile"
+)
+
+// Norms son los valores válidos de --norm.
+var Norms = []string{NormCenter, NormZScore, NormPercentile}
+
+// ValidNorm indica si norm es un valor de --norm.
+func ValidNorm(norm string) bool {
+	for _, n := range Norms {
+		if n == norm {
+			return true
+		}
+	}
+	return false
+}
+
+// rowStats acumula por fila (usuario o ítem) lo que necesita --norm.
+type rowStats struct {
+	norm string
+	sum  []float64
+	sq   []float64 // solo zscore
+	cnt  []int
+	hist []map[float64]int // solo percentile: rating -> veces
+}
+
+func newRowStats(norm string) *rowStats { return &rowStats{norm: norm} }
+
+func (s *rowStats) add(g int, r float64) {
+	if g+1 > len(s.cnt) {
+		s.sum, s.cnt = grow(s.sum, g+1), grow(s.cnt, g+1)
+		switch s.norm {
+		case NormZScore:
+			s.sq = grow(s.sq, g+1)
+		case NormPercentile:
+			s.hist = grow(s.hist, g+1)
+		}
+	}
+	s.sum[g] += r
+	s.cnt[g]++
+	switch s.norm {
+	case NormZScore:
+		s.sq[g] += r * r
+	case NormPercentile:
+		if s.hist[g] == nil {
+			s.hist[g] = make(map[float64]int)
+		}
+		s.hist[g][r]++
+	}
+}
+
+// finish escribe la tabla que corresponde a --norm para axis (stds o ranks;
+// nada con center) y devuelve la ruta escrita ("" si ninguna) junto con la
+// función que normaliza el rating r de la fila g.
+func (s *rowStats) finish(l layout.Layout, axis string) (string, func(g int, r float64) float64, error) {
+	mean := means(s.sum, s.cnt)
+	switch s.norm {
+	case NormZScore:
+		std := make([]float64, len(s.cnt))
+		for g, n := range s.cnt {
+			if n > 0 {
+				std[g] = math.Sqrt(math.Max(0, s.sq[g]/float64(n)-mean[g]*mean[g]))
+			}
+		}
+		path := l.Stds(axis)
+		if err := writeDense(path, "std", std); err != nil {
+			return "", nil, err
+		}
+		return path, func(g int, r float64) float64 {
+			if std[g] == 0 {
+				return 0
+			}
+			return (r - mean[g]) / std[g]
+		}, nil
+	case NormPercentile:
+		tables := make([]RankTable, len(s.hist))
+		for g, h := range s.hist {
+			tables[g] = newRankTable(h)
+		}
+		path := l.Ranks(axis)
+		if err := writeRanks(path, tables); err != nil {
+			return "", nil, err
+		}
+		return path, func(g int, r float64) float64 { return tables[g].Pct(r) - 0.5 }, nil
+	}
+	return "", func(g int, r float64) float64 { return r - mean[g] }, nil
+}
+
+// RankTable es la distribución de ratings de una fila: R ascendente (sin
+// repetidos) y P[k] el percentil por rango medio de R[k].
+type RankTable struct {
+	R, P []float64
+}
+
+func newRankTable(hist map[float64]int) RankTable {
+	var t RankTable
+	n := 0
+	for r, c := range hist {
+		t.R = append(t.R, r)
+		n += c
+	}
+	sort.Float64s(t.R)
+	below := 0
+	for _, r := range t.R {
+		c := hist[r]
+		t.P = append(t.P, (float64(below)+float64(c)/2)/float64(n))
+		below += c
+	}
+	return t
+}
+
+// Pct devuelve el percentil de r, interpolando entre los ratings de la
+// tabla (0.5 si la tabla está vacía).
+func (t RankTable) Pct(r float64) float64 {
+	if len(t.R) == 0 {
+		return 0.5
+	}
+	return interp(t.R, t.P, r)
+}
+
+// Rating es la inversa de Pct: el rating de la fila en el percentil p.
+// La tabla no debe estar vacía.
+func (t RankTable) Rating(p float64) float64 {
+	return interp(t.P, t.R, p)
+}
+
+// interp interpola linealmente y(x) sobre xs ascendente; fuera de rango
+// devuelve el extremo.
+func interp(xs, ys []float64, x float64) float64 {
+	k := sort.SearchFloat64s(xs, x)
+	switch {
+	case k == 0:
+		return ys[0]
+	case k == len(xs):
+		return ys[len(ys)-1]
+	case xs[k] == x:
+		return ys[k]
+	}
+	f := (x - xs[k-1]) / (xs[k] - xs[k-1])
+	return ys[k-1] + f*(ys[k]-ys[k-1])
+}
+
+func writeRanks(path string, tables []RankTable) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{"idx", "rating", "pct"})
+	for g, t := range tables {
+		for k := range t.R {
+			_ = w.Write([]string{strconv.Itoa(g),
+				strconv.FormatFloat(t.R[k], 'f', -1, 64), strconv.FormatFloat(t.P[k], 'f', -1, 64)})
+		}
+	}
+	w.Flush()
+	return errors.Join(w.Error(), f.Close())
+}
+
+// LoadRanks lee <axis>_ranks.csv; la posición g del resultado es la tabla
+// de la fila g (vacía si la fila no tiene ratings).
+func LoadRanks(path string) ([]RankTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReader(f))
+	if _, err := rd.Read(); err != nil { // header
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var out []RankTable
+	for line := 2; ; line++ {
+		rec, err := rd.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		g, err1 := strconv.Atoi(rec[0])
+		r, err2 := strconv.ParseFloat(rec[1], 64)
+		p, err3 := strconv.ParseFloat(rec[2], 64)
+		if err1 != nil || err2 != nil || err3 != nil || g < 0 {
+			return nil, fmt.Errorf("%s:%d: fila inválida %v", path, line, rec)
+		}
+		out = grow(out, g+1)
+		out[g].R = append(out[g].R, r)
+		out[g].P = append(out[g].P, p)
+	}
+	return out, nil
+}
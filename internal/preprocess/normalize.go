@@ -0,0 +1,377 @@
+package preprocess
+
+/*
+NORMALIZACIÓN + CSR por USUARIO e ÍTEM (opcionalmente ambas)
+
+Entrada:
+  - <artifacts>/ratings_ui.bin  // columnas binarias de remap (ver internal/ratings)
+    o, si no existe, <artifacts>/ratings_ui.csv  (uIdx,iIdx,rating[,timestamp])
+
+Salidas (según --axis):
+  - <artifacts>/user_means.csv
+  - <artifacts>/matrix_user_csr/{indptr.bin,indices.bin,data.bin,meta.json}
+
+  - <artifacts>/item_means.csv
+  - <artifacts>/matrix_item_csr/{indptr.bin,indices.bin,data.bin,meta.json}
+
+  Con --ts, cada directorio CSR lleva además ts.bin (int64, paralelo a data.bin).
+
+  Con --raw, además (ratings sin centrar, para coseno/Jaccard y demás
+  kernels que no quieren la media restada):
+  - <artifacts>/matrix_raw_csr/  // filas = usuarios (orden (u,i))
+  - <artifacts>/matrix_raw_csc/  // filas = ítems    (orden (i,u))
+  meta.json lleva "variant": centered | raw (| residual en baseline).
+
+  --norm=zscore|percentile divide por el desvío o usa el percentil dentro de
+  la fila en lugar de solo restar la media, y escribe <axis>_stds.csv o
+  <axis>_ranks.csv (ver norm.go).
+
+  - <artifacts>/normalize_<axis>_report.txt (+ .json, ver internal/report)
+
+  --axis=baseline ajusta μ + b_u + b_i y escribe los residuos (ver baseline.go).
+  --axis=implicit escribe pesos binarios o de confianza (ver implicit.go).
+
+Notas:
+  - Pearson(user-based) usa matrix_user_csr (centrado por usuario).
+  - Pearson(item-based) usa matrix_item_csr (centrado por ítem).
+  - Coseno item-based puede seguir usando ratings_ui.csv (no requiere centrar).
+  - Los .bin y meta.json los escribe csr.StreamWriter (meta incluye "axis"):
+    los triplets pasan por un ordenamiento externo (extsort.go) en orden
+    (u,i) o (i,u) y se vuelcan sin armar la matriz en memoria. Con
+    --mem_limit el ordenamiento usa runs en disco; solo medias e indptr
+    (O(U+I)) quedan en RAM.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/csr"
+	"pc3/internal/layout"
+	"pc3/internal/report"
+)
+
+// NormalizeOptions controla qué genera Normalize.
+type NormalizeOptions struct {
+	Axis     string `json:"axis"`      // user | item | both | baseline | implicit
+	Norm     string `json:"norm"`      // center | zscore | percentile (no aplica a baseline)
+	Raw      bool   `json:"raw"`       // además matrix_raw_csr y matrix_raw_csc sin centrar
+	TS       bool   `json:"ts"`        // guardar ts.bin junto a cada CSR
+	MemLimit int64  `json:"mem_limit"` // bytes para ordenar en memoria (0 = sin límite)
+
+	// solo --axis=baseline (ver baseline.go)
+	RegUser float64 `json:"reg_user,omitempty"` // λ de los sesgos de usuario
+	RegItem float64 `json:"reg_item,omitempty"` // λ de los sesgos de ítem
+	Iters   int     `json:"iters,omitempty"`    // iteraciones de mínimos cuadrados alternados
+
+	// solo --axis=implicit (ver implicit.go)
+	ImplicitMode string  `json:"implicit_mode,omitempty"` // binary | confidence
+	Threshold    float64 `json:"threshold,omitempty"`     // binary: rating mínimo positivo
+	Alpha        float64 `json:"alpha,omitempty"`         // confidence: w = 1 + α·r
+}
+
+// Normalize calcula medias y CSR normalizados según opt.Norm para
+// opt.Axis = user | item | both, los sesgos regularizados y el CSR de
+// residuos para baseline, o los pesos de feedback implícito para implicit.
+func Normalize(l layout.Layout, opt NormalizeOptions) error {
+	axis := opt.Axis
+	if opt.Norm == "" {
+		opt.Norm = NormCenter
+	}
+	if axis != AxisBaseline && axis != AxisImplicit && !ValidNorm(opt.Norm) {
+		return fmt.Errorf("--norm debe ser center, zscore o percentile (recibido %q)", opt.Norm)
+	}
+	switch axis {
+	case "user", "item", "both":
+	case AxisBaseline:
+		return normalizeBaseline(l, opt)
+	case AxisImplicit:
+		return normalizeImplicit(l, opt)
+	default:
+		return fmt.Errorf("--axis debe ser user, item, both, baseline o implicit (recibido %q)", axis)
+	}
+	doUser, doItem := axis == "user" || axis == "both", axis == "item" || axis == "both"
+	t0 := time.Now()
+	rep := report.New("normalize", opt)
+
+	// Cada eje necesita los triplets en su orden (u,i) o (i,u); --raw usa
+	// ambos órdenes (CSR y CSC). Con dos ordenadores el presupuesto de
+	// memoria se reparte entre ellos.
+	needUser, needItem := doUser || opt.Raw, doItem || opt.Raw
+	limit := opt.MemLimit
+	if needUser && needItem {
+		limit /= 2
+	}
+	var byUser, byItem *tripletSorter
+	if needUser {
+		byUser = newTripletSorter(l.TmpDir(), limit, byUserItem)
+		defer byUser.Close()
+	}
+	if needItem {
+		byItem = newTripletSorter(l.TmpDir(), limit, byItemUser)
+		defer byItem.Close()
+	}
+
+	// --- PASO 1: leer triplets una vez: tamaños, estadísticas por fila y orden ---
+	userSt, itemSt := newRowStats(opt.Norm), newRowStats(opt.Norm)
+	var sum float64
+	NNZ := 0
+	src, err := eachTriplet(l, opt.TS, func(tr Triplet) error {
+		NNZ++
+		sum += tr.R
+		userSt.add(tr.U, tr.R)
+		itemSt.add(tr.I, tr.R)
+
+		if byUser != nil {
+			if err := byUser.Add(tr); err != nil {
+				return fmt.Errorf("volcando run temporal: %w", err)
+			}
+		}
+		if byItem != nil {
+			if err := byItem.Add(tr); err != nil {
+				return fmt.Errorf("volcando run temporal: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	rep.Time("read", time.Since(t0))
+	U, I := len(userSt.cnt), len(itemSt.cnt)
+
+	// --- USER: medias + CSR centrado por usuario y/o CSR crudo (orden (u,i)) ---
+	if needUser {
+		t1 := time.Now()
+		var sinks []csrSink
+		if doUser {
+			if err := writeMeansDense(l.Means(csr.AxisUser), userSt.sum, userSt.cnt); err != nil {
+				return fmt.Errorf("escribiendo user_means: %w", err)
+			}
+			extra, norm, err := userSt.finish(l, csr.AxisUser)
+			if err != nil {
+				return fmt.Errorf("escribiendo tabla de --norm=%s: %w", opt.Norm, err)
+			}
+			sinks = append(sinks, csrSink{
+				dir:  l.CSRDir(csr.AxisUser),
+				meta: csr.Meta{Users: U, Items: I, Axis: csr.AxisUser, Norm: metaNorm(opt.Norm), Variant: csr.VariantCentered},
+				cell: func(t Triplet) (int, int32, float64) { return t.U, int32(t.I), norm(t.U, t.R) },
+			})
+			rep.Output("user_means", l.Means(csr.AxisUser))
+			if extra != "" {
+				rep.Output("user_"+opt.Norm, extra)
+			}
+			rep.Output("user_csr", l.CSRDir(csr.AxisUser))
+		}
+		if opt.Raw {
+			sinks = append(sinks, csrSink{
+				dir:  l.RawDir(csr.AxisUser),
+				meta: csr.Meta{Users: U, Items: I, Axis: csr.AxisUser, Variant: csr.VariantRaw},
+				cell: func(t Triplet) (int, int32, float64) { return t.U, int32(t.I), t.R },
+			})
+			rep.Output("raw_csr", l.RawDir(csr.AxisUser))
+		}
+
+		// Con los triplets en orden (u,i) cada CSR se escribe en la misma pasada.
+		if err := writeNormalized(byUser, opt.TS, sinks...); err != nil {
+			return err
+		}
+		for _, sk := range sinks {
+			fmt.Printf("[OK] USER CSR (%s) -> U=%d I=%d NNZ=%d  out=%s%s\n", sk.meta.Variant, U, I, NNZ, sk.dir, runsNote(byUser))
+		}
+		rep.Time("user_csr", time.Since(t1))
+		rep.Count("user_runs", int64(byUser.Runs()))
+	}
+
+	// --- ITEM: medias + CSR centrado por ítem y/o CSC crudo (orden (i,u)) ---
+	if needItem {
+		t1 := time.Now()
+		var sinks []csrSink
+		if doItem {
+			if err := writeMeansDense(l.Means(csr.AxisItem), itemSt.sum, itemSt.cnt); err != nil {
+				return fmt.Errorf("escribiendo item_means: %w", err)
+			}
+			extra, norm, err := itemSt.finish(l, csr.AxisItem)
+			if err != nil {
+				return fmt.Errorf("escribiendo tabla de --norm=%s: %w", opt.Norm, err)
+			}
+			// Filas = ítems, columnas = uIdx.
+			sinks = append(sinks, csrSink{
+				dir:  l.CSRDir(csr.AxisItem),
+				meta: csr.Meta{Users: U, Items: I, Axis: csr.AxisItem, Norm: metaNorm(opt.Norm), Variant: csr.VariantCentered},
+				cell: func(t Triplet) (int, int32, float64) { return t.I, int32(t.U), norm(t.I, t.R) },
+			})
+			rep.Output("item_means", l.Means(csr.AxisItem))
+			if extra != "" {
+				rep.Output("item_"+opt.Norm, extra)
+			}
+			rep.Output("item_csr", l.CSRDir(csr.AxisItem))
+		}
+		if opt.Raw {
+			sinks = append(sinks, csrSink{
+				dir:  l.RawDir(csr.AxisItem),
+				meta: csr.Meta{Users: U, Items: I, Axis: csr.AxisItem, Variant: csr.VariantRaw},
+				cell: func(t Triplet) (int, int32, float64) { return t.I, int32(t.U), t.R },
+			})
+			rep.Output("raw_csc", l.RawDir(csr.AxisItem))
+		}
+
+		if err := writeNormalized(byItem, opt.TS, sinks...); err != nil {
+			return err
+		}
+		for _, sk := range sinks {
+			fmt.Printf("[OK] ITEM CSR (%s) -> U=%d I=%d NNZ=%d  out=%s%s\n", sk.meta.Variant, U, I, NNZ, sk.dir, runsNote(byItem))
+		}
+		rep.Time("item_csr", time.Since(t1))
+		rep.Count("item_runs", int64(byItem.Runs()))
+	}
+
+	rep.Time("total", time.Since(t0))
+	rep.Count("users", int64(U))
+	rep.Count("items", int64(I))
+	rep.Count("nnz", int64(NNZ))
+	rep.Input("triplets", src)
+	rep.Metric("global_mean", sum/float64(max(1, NNZ)))
+	return writeNormalizeReport(l.NormalizeReport(axis), axis, opt.Norm, rep)
+}
+
+// writeNormalizeReport escribe el reporte de texto y su JSON.
+func writeNormalizeReport(path, axis, norm string, rep *report.Report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "== NORMALIZE (%s) ==\n", axis)
+	fmt.Fprintf(&b, "Normalización  : %s\n", norm)
+	fmt.Fprintf(&b, "Entrada        : %s\n", rep.Inputs["triplets"])
+	fmt.Fprintf(&b, "Usuarios (U)   : %d\n", rep.Counts["users"])
+	fmt.Fprintf(&b, "Items (I)      : %d\n", rep.Counts["items"])
+	fmt.Fprintf(&b, "Ratings (NNZ)  : %d\n", rep.Counts["nnz"])
+	fmt.Fprintf(&b, "Media global   : %.4f\n\n", rep.Metrics["global_mean"])
+	fmt.Fprintf(&b, "Tiempos:\n")
+	for _, ph := range []struct{ key, label string }{
+		{"read", "Leer triplets"}, {"user_csr", "CSR usuario"}, {"item_csr", "CSR ítem"}, {"total", "TOTAL"},
+	} {
+		if v, ok := rep.Timings[ph.key]; ok {
+			fmt.Fprintf(&b, "  %-14s: %.3fs\n", ph.label, v)
+		}
+	}
+	fmt.Fprintf(&b, "\nSalidas:\n")
+	for _, k := range []string{
+		"user_means", "user_" + norm, "user_csr", "item_means", "item_" + norm, "item_csr", "raw_csr", "raw_csc",
+	} {
+		if v, ok := rep.Outputs[k]; ok {
+			fmt.Fprintf(&b, "  %s\n", v)
+		}
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return err
+	}
+	return rep.Write(report.Path(path))
+}
+
+// metaNorm es el campo norm de meta.json (vacío para el centrado histórico).
+func metaNorm(norm string) string {
+	if norm == NormCenter {
+		return ""
+	}
+	return norm
+}
+
+// csrSink es un CSR de salida; cell da la fila, la columna y el valor (ya
+// normalizado) de cada triplet.
+type csrSink struct {
+	dir  string
+	meta csr.Meta
+	cell func(Triplet) (int, int32, float64)
+}
+
+// writeNormalized vuelca los triplets ordenados de s en cada sink, todos en
+// la misma pasada (comparten el orden de filas).
+func writeNormalized(s *tripletSorter, withTS bool, sinks ...csrSink) error {
+	ws := make([]*csr.StreamWriter, 0, len(sinks))
+	closeAll := func() {
+		for _, w := range ws {
+			_ = w.Close()
+		}
+	}
+	for _, sk := range sinks {
+		w, err := csr.NewStreamWriter(sk.dir, sk.meta, withTS)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("escribiendo %s: %w", sk.dir, err)
+		}
+		ws = append(ws, w)
+	}
+	if err := s.Each(func(t Triplet) error {
+		for k, sk := range sinks {
+			row, col, v := sk.cell(t)
+			if err := ws[k].Append(row, col, float32(v), t.T); err != nil {
+				return fmt.Errorf("escribiendo %s: %w", sk.dir, err)
+			}
+		}
+		return nil
+	}); err != nil {
+		closeAll()
+		return err
+	}
+	for k, w := range ws {
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("escribiendo %s: %w", sinks[k].dir, err)
+		}
+	}
+	return nil
+}
+
+func runsNote(s *tripletSorter) string {
+	if s.Runs() == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  (%d runs en disco)", s.Runs())
+}
+
+// --- utilidades ---
+
+// grow extiende xs con ceros hasta largo n.
+func grow[T any](xs []T, n int) []T {
+	for len(xs) < n {
+		var zero T
+		xs = append(xs, zero)
+	}
+	return xs
+}
+
+func means(sum []float64, cnt []int) []float64 {
+	out := make([]float64, len(sum))
+	for k := range sum {
+		if cnt[k] > 0 {
+			out[k] = sum[k] / float64(cnt[k])
+		}
+	}
+	return out
+}
+
+func writeMeansDense(path string, sum []float64, cnt []int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	defer w.Flush()
+	_ = w.Write([]string{"idx", "mean"})
+	for i := 0; i < len(sum); i++ {
+		var m float64
+		if cnt[i] > 0 {
+			m = sum[i] / float64(cnt[i])
+		}
+		_ = w.Write([]string{strconv.Itoa(i), strconv.FormatFloat(m, 'f', -1, 64)})
+	}
+	return nil
+}
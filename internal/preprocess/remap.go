@@ -0,0 +1,438 @@
+package preprocess
+
+/*
+REMAPPING (userId→uIdx, movieId→iIdx) + TRIPLETS (uIdx,iIdx,rating,timestamp)
+
+Entrada:
+  - <artifacts>/ratings_min5.csv  // resultado del filtrado (≥5 ratings por ítem)
+
+Salidas:
+  - <artifacts>/index/user_map.csv   (userId,uIdx)
+  - <artifacts>/index/item_map.csv   (movieId,iIdx)
+  - <artifacts>/ratings_ui.csv       (uIdx,iIdx,rating,timestamp)  // ordenado por uIdx
+  - <artifacts>/ratings_ui.bin       // mismas filas en columnas binarias (ver internal/ratings)
+  - <artifacts>/remap_report.txt     // resumen (U, I, NNZ; + .json)
+
+Con --incremental se cargan los mapas existentes de index/ y se conservan
+sus índices: solo los ids nuevos reciben uIdx/iIdx a continuación del
+mayor existente, así las similitudes y recomendaciones ya calculadas
+siguen siendo válidas. Los ids del mapa que no aparecen en este volcado
+conservan su índice (quedan sin ratings).
+
+Con --mem_limit los triplets se ordenan por fuera de memoria (ver
+extsort.go): solo los mapas de ids quedan completos en RAM.
+
+ratings_ui.bin es la entrada que prefieren normalize, sim y recommend. Con
+--rating_type=auto (por defecto) los ratings van cuantizados a uint8 si
+todos son múltiplos de 0.5 (MovieLens), si no como float32.
+
+El timestamp (segundos Unix, tal cual viene en ratings.csv) se conserva
+para splits temporales y ponderación por antigüedad; normalize --ts lo
+guarda además como ts.bin en los directorios CSR. Filas sin timestamp
+quedan con 0.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/layout"
+	"pc3/internal/ratings"
+	"pc3/internal/report"
+)
+
+type Triplet struct {
+	U int
+	I int
+	R float64
+	T int64 // timestamp Unix (0 si la fila no lo trae)
+}
+
+// RemapOptions controla cómo se asignan los índices y se ordenan los triplets.
+type RemapOptions struct {
+	MemLimit    int64  `json:"mem_limit"`   // bytes para ordenar en memoria (0 = sin límite)
+	Incremental bool   `json:"incremental"` // partir de los mapas existentes y solo agregar ids nuevos
+	RatingType  string `json:"rating_type"` // columna de ratings en ratings_ui.bin: auto | float32 | uint8
+}
+
+// Remap asigna índices densos a usuarios/películas del CSV filtrado y
+// escribe los triplets ordenados por uIdx junto con ambos mapas.
+func Remap(l layout.Layout, opt RemapOptions) error {
+	inFiltered, outTriplets, outBin := l.Filtered(), l.Triplets(), l.TripletsBin()
+	userMapPath, itemMapPath := l.UserMap(), l.ItemMap()
+	switch opt.RatingType {
+	case "", "auto", "float32", "uint8":
+	default:
+		return fmt.Errorf("--rating_type debe ser auto, float32 o uint8 (recibido %q)", opt.RatingType)
+	}
+
+	if err := os.MkdirAll(l.IndexDir(), 0o755); err != nil {
+		return fmt.Errorf("creando %s: %w", l.IndexDir(), err)
+	}
+
+	t0 := time.Now()
+
+	// 1) Primera pasada: construir mapas userId→uIdx, movieId→iIdx
+	userIdx := make(map[int]int, 200000)
+	itemIdx := make(map[int]int, 80000)
+	var nextU, nextI int
+	if opt.Incremental {
+		var err error
+		if userIdx, nextU, err = readIndexMap(userMapPath); err != nil {
+			return err
+		}
+		if itemIdx, nextI, err = readIndexMap(itemMapPath); err != nil {
+			return err
+		}
+	}
+	prevU, prevI := nextU, nextI
+	var activeU, activeI []bool // ids previos vistos en este volcado
+	if opt.Incremental {
+		activeU, activeI = make([]bool, prevU), make([]bool, prevI)
+	}
+
+	f, err := os.Open(inFiltered)
+	if err != nil {
+		return fmt.Errorf("abriendo %s: %w", inFiltered, err)
+	}
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1
+	_, _ = reader.Read() // header
+
+	sorter := newTripletSorter(l.TmpDir(), opt.MemLimit, byUserItem)
+	defer sorter.Close()
+
+	var nnz int64
+	quantizable := true // todos los ratings caben en uint8 (medias estrellas)
+	for {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		if len(row) < 3 {
+			continue
+		}
+
+		uid, err1 := strconv.Atoi(strings.TrimSpace(row[0]))
+		iid, err2 := strconv.Atoi(strings.TrimSpace(row[1]))
+		r, err3 := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err1 != nil || err2 != nil || err3 != nil {
+			continue
+		}
+		var ts int64
+		if len(row) > 3 {
+			ts, _ = strconv.ParseInt(strings.TrimSpace(row[3]), 10, 64)
+		}
+
+		u, ok := userIdx[uid]
+		if !ok {
+			u = nextU
+			userIdx[uid] = u
+			nextU++
+		}
+		i, ok := itemIdx[iid]
+		if !ok {
+			i = nextI
+			itemIdx[iid] = i
+			nextI++
+		}
+		if u < prevU {
+			activeU[u] = true
+		}
+		if i < prevI {
+			activeI[i] = true
+		}
+
+		if err := sorter.Add(Triplet{U: u, I: i, R: r, T: ts}); err != nil {
+			f.Close()
+			return fmt.Errorf("volcando run temporal: %w", err)
+		}
+		quantizable = quantizable && ratings.Quantizable(r)
+		nnz++
+	}
+	f.Close()
+
+	tRead := time.Since(t0)
+
+	// 2+3) Ordenar por uIdx (para facilitar CSR en el siguiente paso) y
+	// escribir triplets (uIdx,iIdx,rating,timestamp) en CSV y en binario
+	h := ratings.Header{N: nnz, Users: nextU, Items: nextI, Order: ratings.OrderUserItem, HasTS: true}
+	switch {
+	case opt.RatingType == "uint8" && !quantizable:
+		return errors.New("--rating_type=uint8: hay ratings que no son múltiplos de 0.5")
+	case opt.RatingType == "uint8", opt.RatingType != "float32" && quantizable:
+		h.Rating = ratings.RatingUint8
+	}
+	if err := writeTriplets(outTriplets, outBin, h, sorter); err != nil {
+		return fmt.Errorf("escribiendo triplets: %w", err)
+	}
+
+	tWrite := time.Since(t0) - tRead
+
+	// 4) Escribir mapas
+	if err := writeUserMap(userMapPath, userIdx); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", userMapPath, err)
+	}
+	if err := writeItemMap(itemMapPath, itemIdx); err != nil {
+		return fmt.Errorf("escribiendo %s: %w", itemMapPath, err)
+	}
+
+	// 5) Reporte
+	rep := fmt.Sprintf(
+		"== REMAP ==\nUsuarios (U): %d\nItems (I): %d\nRatings (NNZ): %d\nSalida triplets: %s\nSalida binaria: %s (ratings %s)\n",
+		len(userIdx), len(itemIdx), nnz, outTriplets, outBin, ratingTypeName(h.Rating),
+	)
+	if opt.Incremental {
+		rep += fmt.Sprintf(
+			"Modo incremental: mapas previos U=%d I=%d\nUsuarios nuevos: %d\nItems nuevos: %d\nUsuarios previos sin ratings en este volcado: %d\nItems previos sin ratings en este volcado: %d\n",
+			prevU, prevI, nextU-prevU, nextI-prevI, countFalse(activeU), countFalse(activeI),
+		)
+	}
+	if opt.MemLimit > 0 {
+		rep += fmt.Sprintf("Ordenamiento externo: mem_limit=%d bytes, %d runs en disco\n", opt.MemLimit, sorter.Runs())
+	}
+	_ = os.WriteFile(l.RemapReport(), []byte(rep), 0o644)
+
+	jr := report.New("remap", opt)
+	jr.Count("users", int64(len(userIdx)))
+	jr.Count("items", int64(len(itemIdx)))
+	jr.Count("nnz", nnz)
+	jr.Count("sort_runs", int64(sorter.Runs()))
+	if opt.Incremental {
+		jr.Count("prev_users", int64(prevU))
+		jr.Count("prev_items", int64(prevI))
+		jr.Count("new_users", int64(nextU-prevU))
+		jr.Count("new_items", int64(nextI-prevI))
+		jr.Count("idle_prev_users", int64(countFalse(activeU)))
+		jr.Count("idle_prev_items", int64(countFalse(activeI)))
+	}
+	jr.Time("read", tRead)
+	jr.Time("sort_write", tWrite)
+	jr.Time("total", time.Since(t0))
+	jr.Set("rating_type", ratingTypeName(h.Rating))
+	jr.Output("triplets", outTriplets)
+	jr.Output("triplets_bin", outBin)
+	jr.Output("user_map", userMapPath)
+	jr.Output("item_map", itemMapPath)
+	if err := jr.Write(report.Path(l.RemapReport())); err != nil {
+		return err
+	}
+
+	fmt.Printf("[OK] REMAP: U=%d I=%d NNZ=%d\n", len(userIdx), len(itemIdx), nnz)
+	if opt.Incremental {
+		fmt.Printf("  incremental: +%d usuarios, +%d items\n", nextU-prevU, nextI-prevI)
+	}
+	fmt.Printf("  -> %s\n  -> %s\n  -> %s\n  -> %s\n", outTriplets, outBin, userMapPath, itemMapPath)
+	return nil
+}
+
+// writeTriplets recorre el sorter una vez y escribe cada triplet en el CSV
+// y en las columnas de ratings_ui.bin.
+func writeTriplets(csvPath, binPath string, h ratings.Header, sorter *tripletSorter) error {
+	if err := os.MkdirAll(filepath.Dir(csvPath), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(csvPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	bw, err := ratings.NewBinaryWriter(binPath, h)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(bufio.NewWriter(f))
+
+	_ = w.Write([]string{"uIdx", "iIdx", "rating", "timestamp"})
+	err = sorter.Each(func(t Triplet) error {
+		if err := bw.Append(int32(t.U), int32(t.I), t.R, t.T); err != nil {
+			return err
+		}
+		return w.Write([]string{
+			strconv.Itoa(t.U),
+			strconv.Itoa(t.I),
+			strconv.FormatFloat(t.R, 'f', -1, 64),
+			strconv.FormatInt(t.T, 10),
+		})
+	})
+	w.Flush()
+	return errors.Join(err, w.Error(), bw.Close())
+}
+
+// eachTriplet recorre los triplets de remap: desde ratings_ui.bin si existe
+// o, si no, desde ratings_ui.csv. withTS exige timestamps. Devuelve la ruta
+// leída. Ambos se leen en streaming (memoria constante), así normalize y
+// baseline siguen respetando --mem_limit.
+func eachTriplet(l layout.Layout, withTS bool, fn func(Triplet) error) (string, error) {
+	if path := l.TripletsBin(); exists(path) {
+		h, err := ratings.ReadHeader(path)
+		if err != nil {
+			return "", err
+		}
+		if withTS && !h.HasTS {
+			return "", fmt.Errorf("%s no trae timestamp (re-ejecutar remap) y se pidió --ts", path)
+		}
+		err = ratings.EachBinary(path, withTS, func(u, i int32, r float32, ts int64) error {
+			return fn(Triplet{U: int(u), I: int(i), R: float64(r), T: ts})
+		})
+		if err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+
+	path := l.Triplets()
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("abriendo %s: %w", path, err)
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReader(f))
+	rd.FieldsPerRecord = -1
+	header, _ := rd.Read()
+	if withTS && len(header) < 4 {
+		return "", fmt.Errorf("%s no trae timestamp (re-ejecutar remap) y se pidió --ts", path)
+	}
+	for {
+		rec, err := rd.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		if len(rec) < 3 {
+			continue
+		}
+		u, _ := strconv.Atoi(rec[0])
+		i, _ := strconv.Atoi(rec[1])
+		r, _ := strconv.ParseFloat(rec[2], 64)
+		var t int64
+		if withTS && len(rec) > 3 {
+			t, _ = strconv.ParseInt(rec[3], 10, 64)
+		}
+		if err := fn(Triplet{U: u, I: i, R: r, T: t}); err != nil {
+			return "", err
+		}
+	}
+	return path, nil
+}
+
+func ratingTypeName(t uint8) string {
+	if t == ratings.RatingUint8 {
+		return "uint8, medias estrellas"
+	}
+	return "float32"
+}
+
+func writeUserMap(path string, m map[int]int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	defer w.Flush()
+	_ = w.Write([]string{"userId", "uIdx"})
+	// Orden estable (por uIdx)
+	type kv struct{ id, idx int }
+	arr := make([]kv, 0, len(m))
+	for id, idx := range m {
+		arr = append(arr, kv{id, idx})
+	}
+	sort.Slice(arr, func(a, b int) bool { return arr[a].idx < arr[b].idx })
+	for _, kv := range arr {
+		_ = w.Write([]string{strconv.Itoa(kv.id), strconv.Itoa(kv.idx)})
+	}
+	return nil
+}
+func writeItemMap(path string, m map[int]int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := csv.NewWriter(bufio.NewWriter(f))
+	defer w.Flush()
+	_ = w.Write([]string{"movieId", "iIdx"})
+	type kv struct{ id, idx int }
+	arr := make([]kv, 0, len(m))
+	for id, idx := range m {
+		arr = append(arr, kv{id, idx})
+	}
+	sort.Slice(arr, func(a, b int) bool { return arr[a].idx < arr[b].idx })
+	for _, kv := range arr {
+		_ = w.Write([]string{strconv.Itoa(kv.id), strconv.Itoa(kv.idx)})
+	}
+	return nil
+}
+
+// readIndexMap carga un mapa id,idx existente y devuelve el siguiente
+// índice libre. Si el archivo no existe, el mapa arranca vacío.
+func readIndexMap(path string) (map[int]int, int, error) {
+	m := make(map[int]int, 200000)
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, 0, nil
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("abriendo %s: %w", path, err)
+	}
+	defer f.Close()
+	r := csv.NewReader(bufio.NewReader(f))
+	if _, err := r.Read(); err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("leyendo cabecera de %s: %w", path, err)
+	}
+	next := 0
+	used := map[int]bool{}
+	for line := 2; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		id, err1 := strconv.Atoi(strings.TrimSpace(rec[0]))
+		idx, err2 := strconv.Atoi(strings.TrimSpace(rec[1]))
+		if err1 != nil || err2 != nil || idx < 0 {
+			return nil, 0, fmt.Errorf("%s:%d: fila inválida %v", path, line, rec)
+		}
+		if _, dup := m[id]; dup || used[idx] {
+			return nil, 0, fmt.Errorf("%s:%d: id o índice repetido (%d,%d)", path, line, id, idx)
+		}
+		m[id] = idx
+		used[idx] = true
+		next = max(next, idx+1)
+	}
+	return m, next, nil
+}
+
+func countFalse(xs []bool) int {
+	n := 0
+	for _, x := range xs {
+		if !x {
+			n++
+		}
+	}
+	return n
+}
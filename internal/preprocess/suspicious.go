@@ -0,0 +1,256 @@
+package preprocess
+
+/*
+PERFILES SOSPECHOSOS (etapa 2 de clean, después de resolver duplicados)
+
+Cada usuario recibe cuatro señales sobre sus filas (ya sin duplicados):
+
+  entropy   entropía de su distribución de ratings (bits, por media
+            estrella) ≤ --susp_entropy; solo con ≥ --susp_min_ratings (1000)
+  variance  desvío de sus ratings ≤ --susp_std; ídem
+  burst     máximo de ratings dentro de una ventana de --susp_window
+            segundos ≥ --susp_burst (timestamps ≤ 0 no cuentan)
+  volume    ratings ≥ --susp_volume
+
+score = entropy + variance + 2·burst + volume. Es sospechoso con
+score ≥ --susp_score (2): quien puntuó miles de películas con el mismo valor
+suma entropy + variance + volume, una ráfaga alcanza sola, y el volumen solo
+(usuario muy activo pero con ratings variados) no. entropy y variance
+casi siempre se disparan juntas, así que --susp_min_ratings es en la
+práctica el umbral de los perfiles de valor constante: es alto para que un
+perfil chico y monótono (50 ratings de 4 estrellas) no quede marcado.
+
+Salida: <artifacts>/suspicious_users.csv (userId, ratings, entropy, std,
+burst, score, reasons), solo los sospechosos, por score descendente. Se
+escribe siempre; con --exclude_suspicious esos usuarios quedan fuera antes de
+las rondas del k-core, que es donde más pesan: sus filas dominan los bucles
+de pares O(n²) de sim.
+
+Memoria: además de los pares, un timestamp por fila (int64) agrupado por
+usuario para medir ráfagas.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SuspiciousOptions son los umbrales del detector.
+type SuspiciousOptions struct {
+	MinRatings int     `json:"min_ratings"` // ratings mínimos para evaluar entropy/variance
+	Entropy    float64 `json:"entropy"`     // bits
+	Std        float64 `json:"std"`
+	Window     int64   `json:"window"` // segundos
+	Burst      int     `json:"burst"`  // ratings dentro de Window
+	Volume     int     `json:"volume"`
+	Score      int     `json:"score"` // score mínimo para marcar
+}
+
+// DefaultSuspiciousOptions son los umbrales por defecto de clean.
+func DefaultSuspiciousOptions() SuspiciousOptions {
+	return SuspiciousOptions{MinRatings: 1000, Entropy: 0.5, Std: 0.25, Window: 60, Burst: 100, Volume: 2000, Score: 2}
+}
+
+// Validate rechaza umbrales que dejan una señal sin sentido: ventana,
+// ráfaga, volumen, mínimo de ratings y score deben ser positivos.
+func (o SuspiciousOptions) Validate() error {
+	for _, f := range []struct {
+		name string
+		v    int64
+	}{
+		{"--susp_min_ratings", int64(o.MinRatings)},
+		{"--susp_window", o.Window},
+		{"--susp_burst", int64(o.Burst)},
+		{"--susp_volume", int64(o.Volume)},
+		{"--susp_score", int64(o.Score)},
+	} {
+		if f.v <= 0 {
+			return fmt.Errorf("%s debe ser > 0 (recibido %d)", f.name, f.v)
+		}
+	}
+	return nil
+}
+
+// suspect son las señales de un usuario marcado.
+type suspect struct {
+	uid     int
+	n       int
+	entropy float64
+	std     float64
+	burst   int
+	score   int
+	reasons []string
+}
+
+// suspiciousStats resume la detección para los reportes.
+type suspiciousStats struct {
+	Users    int            `json:"users"`    // usuarios marcados
+	Rows     int64          `json:"rows"`     // filas de esos usuarios
+	Reasons  map[string]int `json:"reasons"`  // usuarios marcados con cada señal
+	Excluded bool           `json:"excluded"` // --exclude_suspicious
+}
+
+// detectSuspicious relee path (rating y timestamp de cada fila que quedó
+// tras dedup), puntúa a cada usuario y devuelve los sospechosos marcados en
+// un slice indexado por id denso, junto con su detalle.
+func detectSuspicious(path string, p *ratingPairs, opt SuspiciousOptions) ([]bool, []suspect, error) {
+	nu := len(p.userIdx)
+	hist := make([][10]int32, nu) // medias estrellas 0.5 … 5.0
+	sum := make([]float64, nu)
+	sq := make([]float64, nu)
+	ts := make([][]int64, nu)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+	reader := csv.NewReader(bufio.NewReader(f))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if _, err := reader.Read(); err != nil {
+		return nil, nil, fmt.Errorf("leer cabecera: %w", err)
+	}
+	for k := int32(0); ; {
+		row, err := reader.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		if _, _, ok := parseIDs(row); !ok {
+			continue
+		}
+		row = dedupRow(p, k, row)
+		u := p.u[k]
+		k++
+		if row == nil {
+			continue
+		}
+		r, err := strconv.ParseFloat(strings.TrimSpace(row[2]), 64)
+		if err != nil {
+			continue
+		}
+		b := int(mathRound(r*2)) - 1
+		hist[u][min(max(b, 0), 9)]++
+		sum[u] += r
+		sq[u] += r * r
+		if t, err := strconv.ParseInt(strings.TrimSpace(row[3]), 10, 64); err == nil && t > 0 {
+			ts[u] = append(ts[u], t)
+		}
+	}
+
+	uids := make([]int, nu)
+	for uid, u := range p.userIdx {
+		uids[u] = uid
+	}
+	flagged := make([]bool, nu)
+	var out []suspect
+	for u := 0; u < nu; u++ {
+		s := suspect{uid: uids[u]}
+		for _, c := range hist[u] {
+			s.n += int(c)
+		}
+		if s.n == 0 {
+			continue
+		}
+		n := float64(s.n)
+		for _, c := range hist[u] {
+			if c > 0 {
+				q := float64(c) / n
+				s.entropy -= q * math.Log2(q)
+			}
+		}
+		mean := sum[u] / n
+		s.std = math.Sqrt(math.Max(0, sq[u]/n-mean*mean))
+		s.burst = maxInWindow(ts[u], opt.Window)
+		ts[u] = nil
+
+		if s.n >= opt.MinRatings && s.entropy <= opt.Entropy {
+			s.score++
+			s.reasons = append(s.reasons, "entropy")
+		}
+		if s.n >= opt.MinRatings && s.std <= opt.Std {
+			s.score++
+			s.reasons = append(s.reasons, "variance")
+		}
+		if s.burst >= opt.Burst {
+			s.score += 2
+			s.reasons = append(s.reasons, "burst")
+		}
+		if s.n >= opt.Volume {
+			s.score++
+			s.reasons = append(s.reasons, "volume")
+		}
+		if s.score >= opt.Score {
+			flagged[u] = true
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].score != out[b].score {
+			return out[a].score > out[b].score
+		}
+		return out[a].uid < out[b].uid
+	})
+	return flagged, out, nil
+}
+
+// maxInWindow devuelve la mayor cantidad de timestamps de ts dentro de una
+// ventana de w segundos (ordena ts). Con w ≤ 0 la ventana está vacía: 0.
+func maxInWindow(ts []int64, w int64) int {
+	if w <= 0 {
+		return 0
+	}
+	sort.Slice(ts, func(a, b int) bool { return ts[a] < ts[b] })
+	best := 0
+	for a, b := 0, 0; b < len(ts); b++ {
+		for ts[b]-ts[a] >= w {
+			a++
+		}
+		best = max(best, b-a+1)
+	}
+	return best
+}
+
+// summarizeSuspicious cuenta usuarios por señal y filas de los marcados.
+func summarizeSuspicious(out []suspect, excluded bool) suspiciousStats {
+	st := suspiciousStats{Users: len(out), Reasons: map[string]int{}, Excluded: excluded}
+	for _, s := range out {
+		st.Rows += int64(s.n)
+		for _, r := range s.reasons {
+			st.Reasons[r]++
+		}
+	}
+	return st
+}
+
+func writeSuspicious(path string, out []suspect) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(bufio.NewWriter(f))
+	_ = w.Write([]string{"userId", "ratings", "entropy", "std", "burst", "score", "reasons"})
+	for _, s := range out {
+		_ = w.Write([]string{
+			strconv.Itoa(s.uid), strconv.Itoa(s.n),
+			strconv.FormatFloat(s.entropy, 'f', 4, 64), strconv.FormatFloat(s.std, 'f', 4, 64),
+			strconv.Itoa(s.burst), strconv.Itoa(s.score), strings.Join(s.reasons, "|"),
+		})
+	}
+	w.Flush()
+	return errors.Join(w.Error(), f.Close())
+}
@@ -0,0 +1,52 @@
+package preprocess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaxInWindow(t *testing.T) {
+	cases := []struct {
+		name string
+		ts   []int64
+		w    int64
+		want int
+	}{
+		{"vacío", nil, 60, 0},
+		{"ráfaga", []int64{100, 0, 30, 59, 101, 102}, 60, 4},
+		{"borde_excluido", []int64{0, 60, 120}, 60, 1},
+		{"iguales", []int64{5, 5, 5, 9}, 1, 3},
+		{"ventana_cero", []int64{5, 5, 5}, 0, 0},
+		{"ventana_negativa", []int64{1, 2, 3}, -10, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := maxInWindow(tc.ts, tc.w); got != tc.want {
+				t.Fatalf("maxInWindow(%v, %d) = %d, se espera %d", tc.ts, tc.w, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSuspiciousOptionsValidate(t *testing.T) {
+	if err := DefaultSuspiciousOptions().Validate(); err != nil {
+		t.Fatalf("defaults: %v", err)
+	}
+	cases := map[string]func(*SuspiciousOptions){
+		"--susp_min_ratings": func(o *SuspiciousOptions) { o.MinRatings = 0 },
+		"--susp_window":      func(o *SuspiciousOptions) { o.Window = -1 },
+		"--susp_burst":       func(o *SuspiciousOptions) { o.Burst = 0 },
+		"--susp_volume":      func(o *SuspiciousOptions) { o.Volume = -5 },
+		"--susp_score":       func(o *SuspiciousOptions) { o.Score = 0 },
+	}
+	for flag, edit := range cases {
+		t.Run(flag, func(t *testing.T) {
+			o := DefaultSuspiciousOptions()
+			edit(&o)
+			err := o.Validate()
+			if err == nil || !strings.Contains(err.Error(), flag) {
+				t.Fatalf("error %v, se espera uno sobre %s", err, flag)
+			}
+		})
+	}
+}
@@ -0,0 +1,384 @@
+package ratings
+
+/*
+TRIPLETS BINARIOS COLUMNARES (<artifacts>/ratings_ui.bin)
+
+Lo escribe remap junto a ratings_ui.csv; los lectores (sim, normalize,
+recommend) lo prefieren si existe: cada columna se lee de un bloque
+contiguo, sin encoding/csv ni strconv.
+
+Cabecera (64 bytes, little-endian):
+   0  magic    "PC3T"
+   4  version  uint16 (1)
+   6  rating   uint8  (0 = float32, 1 = uint8 cuantizado: r = q/2)
+   7  order    uint8  (0 = sin orden, 1 = (uIdx,iIdx))
+   8  n        uint64 (filas)
+  16  users    uint32 (max uIdx + 1)
+  20  items    uint32 (max iIdx + 1)
+  24  flags    uint32 (bit 0: hay columna de timestamps)
+  28  reservado (ceros)
+
+Columnas, en este orden a partir del byte 64:
+  uIdx int32 × n | iIdx int32 × n | rating (float32 o uint8) × n | ts int64 × n (opcional)
+
+La cuantización uint8 guarda medias estrellas (MovieLens: 0.5 … 5.0) en un
+byte por rating; solo se usa si todos los ratings son múltiplos de 0.5.
+*/
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"pc3/internal/csr"
+)
+
+// Tipos de la columna de ratings.
+const (
+	RatingFloat32 = 0
+	RatingUint8   = 1
+)
+
+// Órdenes de las filas.
+const (
+	OrderNone     = 0
+	OrderUserItem = 1
+)
+
+const (
+	binMagic   = "PC3T"
+	binVersion = 1
+	headerSize = 64
+	flagTS     = 1
+)
+
+// Header describe un archivo de triplets binario.
+type Header struct {
+	N      int64
+	Users  int
+	Items  int
+	Rating uint8 // RatingFloat32 | RatingUint8
+	Order  uint8 // OrderNone | OrderUserItem
+	HasTS  bool
+}
+
+func (h Header) ratingWidth() int64 {
+	if h.Rating == RatingUint8 {
+		return 1
+	}
+	return 4
+}
+
+// offsets de cada columna.
+func (h Header) offsets() (u, i, r, ts int64) {
+	u = headerSize
+	i = u + 4*h.N
+	r = i + 4*h.N
+	ts = r + h.ratingWidth()*h.N
+	return
+}
+
+// Quantizable indica si r cabe en la columna uint8 (múltiplo de 0.5 en [0, 127.5]).
+func Quantizable(r float64) bool {
+	q := r * 2
+	return q >= 0 && q <= 255 && q == math.Trunc(q)
+}
+
+// Triplets son las columnas leídas de un archivo binario.
+type Triplets struct {
+	Header
+	U, I []int32
+	R    []float32
+	TS   []int64 // nil si no se pidió o no existe
+}
+
+// BinaryWriter escribe las columnas en paralelo (cada una con su propio
+// buffer sobre su región del archivo), así las filas pueden llegar de a una.
+type BinaryWriter struct {
+	f        *os.File
+	h        Header
+	cols     []*bufio.Writer
+	rows     int64
+	buf      [8]byte
+	rWriter  *bufio.Writer
+	tsWriter *bufio.Writer
+}
+
+// NewBinaryWriter crea path para exactamente h.N filas.
+func NewBinaryWriter(path string, h Header) (*BinaryWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &BinaryWriter{f: f, h: h}
+	var hb [headerSize]byte
+	copy(hb[0:], binMagic)
+	binary.LittleEndian.PutUint16(hb[4:], binVersion)
+	hb[6], hb[7] = h.Rating, h.Order
+	binary.LittleEndian.PutUint64(hb[8:], uint64(h.N))
+	binary.LittleEndian.PutUint32(hb[16:], uint32(h.Users))
+	binary.LittleEndian.PutUint32(hb[20:], uint32(h.Items))
+	if h.HasTS {
+		binary.LittleEndian.PutUint32(hb[24:], flagTS)
+	}
+	if _, err := f.Write(hb[:]); err != nil {
+		f.Close()
+		return nil, err
+	}
+	uOff, iOff, rOff, tsOff := h.offsets()
+	col := func(off int64) *bufio.Writer {
+		bw := bufio.NewWriterSize(io.NewOffsetWriter(f, off), 1<<20)
+		w.cols = append(w.cols, bw)
+		return bw
+	}
+	col(uOff)
+	col(iOff)
+	w.rWriter = col(rOff)
+	if h.HasTS {
+		w.tsWriter = col(tsOff)
+	}
+	return w, nil
+}
+
+// Append agrega una fila.
+func (w *BinaryWriter) Append(u, i int32, r float64, ts int64) error {
+	if w.rows == w.h.N {
+		return fmt.Errorf("ratings: más filas que las %d declaradas", w.h.N)
+	}
+	w.rows++
+	binary.LittleEndian.PutUint32(w.buf[:4], uint32(u))
+	if _, err := w.cols[0].Write(w.buf[:4]); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(w.buf[:4], uint32(i))
+	if _, err := w.cols[1].Write(w.buf[:4]); err != nil {
+		return err
+	}
+	if w.h.Rating == RatingUint8 {
+		if !Quantizable(r) {
+			return fmt.Errorf("ratings: %v no es cuantizable a medias estrellas", r)
+		}
+		if err := w.rWriter.WriteByte(uint8(r * 2)); err != nil {
+			return err
+		}
+	} else {
+		binary.LittleEndian.PutUint32(w.buf[:4], math.Float32bits(float32(r)))
+		if _, err := w.rWriter.Write(w.buf[:4]); err != nil {
+			return err
+		}
+	}
+	if w.tsWriter != nil {
+		binary.LittleEndian.PutUint64(w.buf[:], uint64(ts))
+		if _, err := w.tsWriter.Write(w.buf[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close vacía las columnas y verifica que se escribieron N filas.
+func (w *BinaryWriter) Close() error {
+	var errs []error
+	for _, c := range w.cols {
+		errs = append(errs, c.Flush())
+	}
+	if w.rows != w.h.N {
+		errs = append(errs, fmt.Errorf("ratings: se escribieron %d filas de %d declaradas", w.rows, w.h.N))
+	}
+	errs = append(errs, w.f.Close())
+	return errors.Join(errs...)
+}
+
+// ReadHeader lee y valida la cabecera.
+func ReadHeader(path string) (Header, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Header{}, err
+	}
+	defer f.Close()
+	return readHeader(f, path)
+}
+
+func readHeader(f *os.File, path string) (Header, error) {
+	var hb [headerSize]byte
+	if _, err := io.ReadFull(f, hb[:]); err != nil {
+		return Header{}, fmt.Errorf("%s: cabecera: %w", path, err)
+	}
+	if string(hb[0:4]) != binMagic {
+		return Header{}, fmt.Errorf("%s: no es un archivo de triplets (magic %q)", path, hb[0:4])
+	}
+	if v := binary.LittleEndian.Uint16(hb[4:]); v != binVersion {
+		return Header{}, fmt.Errorf("%s: versión %d no soportada", path, v)
+	}
+	h := Header{
+		Rating: hb[6],
+		Order:  hb[7],
+		N:      int64(binary.LittleEndian.Uint64(hb[8:])),
+		Users:  int(binary.LittleEndian.Uint32(hb[16:])),
+		Items:  int(binary.LittleEndian.Uint32(hb[20:])),
+		HasTS:  binary.LittleEndian.Uint32(hb[24:])&flagTS != 0,
+	}
+	if h.Rating != RatingFloat32 && h.Rating != RatingUint8 {
+		return Header{}, fmt.Errorf("%s: tipo de rating %d desconocido", path, h.Rating)
+	}
+	st, err := f.Stat()
+	if err != nil {
+		return Header{}, err
+	}
+	_, _, _, end := h.offsets()
+	if h.HasTS {
+		end += 8 * h.N
+	}
+	if st.Size() != end {
+		return Header{}, fmt.Errorf("%s: tamaño %d, la cabecera declara %d", path, st.Size(), end)
+	}
+	return h, nil
+}
+
+// ReadBinary lee las columnas completas en memoria; la de timestamps solo
+// si withTS (y existe). Para recorrer el archivo sin cargarlo, EachBinary.
+func ReadBinary(path string, withTS bool) (*Triplets, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	h, err := readHeader(f, path)
+	if err != nil {
+		return nil, err
+	}
+	uOff, iOff, rOff, tsOff := h.offsets()
+	t := &Triplets{Header: h}
+
+	n := int(h.N)
+	b, err := readSection(f, uOff, 4*n)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.U = make([]int32, n)
+	for k := range t.U {
+		t.U[k] = int32(binary.LittleEndian.Uint32(b[4*k:]))
+	}
+	if _, err := f.ReadAt(b, iOff); err != nil { // mismo tamaño: reusar
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	t.I = make([]int32, n)
+	for k := range t.I {
+		t.I[k] = int32(binary.LittleEndian.Uint32(b[4*k:]))
+	}
+	t.R = make([]float32, n)
+	if h.Rating == RatingUint8 {
+		if _, err := f.ReadAt(b[:n], rOff); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for k := range t.R {
+			t.R[k] = float32(b[k]) / 2
+		}
+	} else {
+		if _, err := f.ReadAt(b, rOff); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for k := range t.R {
+			t.R[k] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*k:]))
+		}
+	}
+	if withTS && h.HasTS {
+		tb, err := readSection(f, tsOff, 8*n)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		t.TS = make([]int64, n)
+		for k := range t.TS {
+			t.TS[k] = int64(binary.LittleEndian.Uint64(tb[8*k:]))
+		}
+	}
+	return t, nil
+}
+
+// streamRows son las filas que EachBinary decodifica por bloque (~1.3 MB de
+// buffers con timestamps).
+const streamRows = 1 << 16
+
+// EachBinary recorre las filas en orden leyendo las columnas por bloques de
+// streamRows, sin cargar el archivo: la memoria no depende de n, así los
+// lectores que respetan --mem_limit (normalize, baseline) pueden usar el
+// .bin. ts es 0 si no se pidió withTS o el archivo no trae timestamps. Para
+// tener todo en memoria, ReadBinary.
+func EachBinary(path string, withTS bool, fn func(u, i int32, r float32, ts int64) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	h, err := readHeader(f, path)
+	if err != nil {
+		return err
+	}
+	uOff, iOff, rOff, tsOff := h.offsets()
+	withTS = withTS && h.HasTS
+	rw := h.ratingWidth()
+
+	ub := make([]byte, 4*streamRows)
+	ib := make([]byte, 4*streamRows)
+	rb := make([]byte, int(rw)*streamRows)
+	var tb []byte
+	if withTS {
+		tb = make([]byte, 8*streamRows)
+	}
+	for lo := int64(0); lo < h.N; lo += streamRows {
+		n := min(h.N-lo, streamRows)
+		if _, err := f.ReadAt(ub[:4*n], uOff+4*lo); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := f.ReadAt(ib[:4*n], iOff+4*lo); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if _, err := f.ReadAt(rb[:rw*n], rOff+rw*lo); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if withTS {
+			if _, err := f.ReadAt(tb[:8*n], tsOff+8*lo); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		for k := int64(0); k < n; k++ {
+			var r float32
+			if h.Rating == RatingUint8 {
+				r = float32(rb[k]) / 2
+			} else {
+				r = math.Float32frombits(binary.LittleEndian.Uint32(rb[4*k:]))
+			}
+			var ts int64
+			if withTS {
+				ts = int64(binary.LittleEndian.Uint64(tb[8*k:]))
+			}
+			u := int32(binary.LittleEndian.Uint32(ub[4*k:]))
+			i := int32(binary.LittleEndian.Uint32(ib[4*k:]))
+			if err := fn(u, i, r, ts); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func readSection(f *os.File, off int64, size int) ([]byte, error) {
+	b := make([]byte, size)
+	if _, err := f.ReadAt(b, off); err != nil && !(err == io.EOF && size == 0) {
+		return nil, err
+	}
+	return b, nil
+}
+
+// LoadBinary carga el archivo binario como CSR usuario×ítem (ver LoadCSV).
+func LoadBinary(path string) (*csr.Matrix, error) {
+	t, err := ReadBinary(path, false)
+	if err != nil {
+		return nil, err
+	}
+	return FromTriplets(t.U, t.I, t.R)
+}
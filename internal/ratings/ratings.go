@@ -0,0 +1,103 @@
+package ratings
+
+/*
+TRIPLETS (uIdx,iIdx,rating) → CSR en memoria
+
+Lee artifacts/ratings_ui.csv (salida de remap; ver binary.go para la
+versión columnar ratings_ui.bin) y arma la matriz usuario×ítem
+en formato CSR (filas = usuarios, columnas = ítems, valores = rating crudo),
+la misma estructura que abre internal/csr para los directorios binarios.
+
+No se asume orden: las filas se agrupan por uIdx con un conteo y volcado,
+conservando el orden de aparición dentro de cada usuario.
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"pc3/internal/csr"
+)
+
+// LoadCSV carga un CSV de triplets (con cabecera) como CSR usuario×ítem.
+func LoadCSV(path string) (*csr.Matrix, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("abrir %s: %w", path, err)
+	}
+	defer f.Close()
+
+	rd := csv.NewReader(bufio.NewReaderSize(f, 1<<20))
+	rd.FieldsPerRecord = -1
+	rd.ReuseRecord = true
+	if _, err := rd.Read(); err != nil {
+		return nil, fmt.Errorf("leer cabecera de %s: %w", path, err)
+	}
+
+	us := make([]int32, 0, 1_000_000)
+	is := make([]int32, 0, 1_000_000)
+	rs := make([]float32, 0, 1_000_000)
+	for {
+		rec, err := rd.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			continue
+		}
+		if len(rec) < 3 {
+			continue
+		}
+		u, err1 := strconv.Atoi(rec[0])
+		i, err2 := strconv.Atoi(rec[1])
+		r, err3 := strconv.ParseFloat(rec[2], 32)
+		if err1 != nil || err2 != nil || err3 != nil || u < 0 || i < 0 {
+			continue
+		}
+		us = append(us, int32(u))
+		is = append(is, int32(i))
+		rs = append(rs, float32(r))
+	}
+	return FromTriplets(us, is, rs)
+}
+
+// FromTriplets arma la CSR usuario×ítem a partir de tres columnas paralelas.
+// U e I se toman como max(índice)+1.
+func FromTriplets(us, is []int32, rs []float32) (*csr.Matrix, error) {
+	if len(us) != len(is) || len(us) != len(rs) {
+		return nil, fmt.Errorf("triplets desalineados: u=%d i=%d r=%d", len(us), len(is), len(rs))
+	}
+	var U, I int
+	for p := range us {
+		if int(us[p])+1 > U {
+			U = int(us[p]) + 1
+		}
+		if int(is[p])+1 > I {
+			I = int(is[p]) + 1
+		}
+	}
+
+	indptr := make([]int64, U+1)
+	for _, u := range us {
+		indptr[u+1]++
+	}
+	for u := 0; u < U; u++ {
+		indptr[u+1] += indptr[u]
+	}
+	indices := make([]int32, len(us))
+	data := make([]float32, len(us))
+	pos := make([]int64, U)
+	copy(pos, indptr)
+	for p, u := range us {
+		q := pos[u]
+		indices[q] = is[p]
+		data[q] = rs[p]
+		pos[u]++
+	}
+	return csr.New(csr.Meta{Users: U, Items: I, Axis: csr.AxisUser}, indptr, indices, data)
+}
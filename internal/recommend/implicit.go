@@ -0,0 +1,335 @@
+package recommend
+
+/*
+RECOMMEND --implicit (feedback implícito)
+
+Con pesos de normalize --axis=implicit no hay rating que predecir: cada
+usuario recibe una lista de los ítems que no vio, ordenada por puntaje,
+y se evalúa solo la lista.
+
+- Interacciones: los ratings pasan por Implicit.Weight (binary descarta los
+  ratings < threshold y deja w = 1; confidence deja w = 1 + α·r).
+- Split hold-out por usuario (test_ratio) sobre sus interacciones.
+- Puntaje de un ítem i no visto por u:
+    * user-based: Σ_v s(u,v)·w_v,i   sobre los vecinos v de u que interactuaron con i
+    * item-based: Σ_j s(i,j)·w_u,j   sobre los ítems j de u en train vecinos de i
+  Solo entran ítems con puntaje > 0; empates por índice de ítem.
+- Métricas sobre el Top-K (K = --k_metrics) con relevantes = ítems de test
+  de u: Precision@K = aciertos/K, Recall@K = aciertos/|test|, NDCG@K,
+  HitRate@K, y la cobertura del catálogo (ítems recomendados al menos una
+  vez / ítems). MAE/RMSE no aplican; --rel_th tampoco (la relevancia es la
+  interacción).
+*/
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"pc3/internal/catalog"
+	"pc3/internal/layout"
+	"pc3/internal/preprocess"
+	"pc3/internal/report"
+)
+
+// scored es un ítem candidato con su puntaje.
+type scored struct {
+	i     int
+	score float64
+}
+
+func runImplicit(l layout.Layout, opt Options, simPath, reportPath string) error {
+	model, kEval, kMetrics := opt.Model, opt.KEval, opt.KMetrics
+	if kMetrics <= 0 {
+		return fmt.Errorf("--implicit requiere --k_metrics > 0 (recibido %d)", kMetrics)
+	}
+	imp, err := preprocess.LoadImplicit(l.Implicit())
+	if err != nil {
+		return fmt.Errorf("--implicit requiere normalize --axis=implicit: %w", err)
+	}
+	t0 := time.Now()
+
+	// -------------------------------------------------------------------------
+	// 1) Ratings -> interacciones con peso
+	// -------------------------------------------------------------------------
+	users := make(map[int][]ur)
+	items := make(map[int][]ir)
+	ratingsSrc, err := loadRatings(l, users, items)
+	if err != nil {
+		return err
+	}
+	var nInter int
+	for u, lst := range users {
+		kept := lst[:0]
+		for _, x := range lst {
+			if w, ok := imp.Weight(x.r); ok {
+				kept = append(kept, ur{x.i, w})
+			}
+		}
+		users[u] = kept
+		nInter += len(kept)
+	}
+	tLoadRatings := time.Since(t0)
+
+	// -------------------------------------------------------------------------
+	// 2) Similitudes
+	// -------------------------------------------------------------------------
+	sim, err := loadSim(simPath)
+	if err != nil {
+		return err
+	}
+	tLoadSim := time.Since(t0) - tLoadRatings
+
+	// -------------------------------------------------------------------------
+	// 3) Split hold-out por usuario; los usuarios sin split quedan enteros
+	//    en train (son vecinos de los demás)
+	// -------------------------------------------------------------------------
+	s0 := time.Now()
+	rand.Seed(time.Now().UnixNano())
+	train := make(map[int]map[int]float64, len(users)) // u -> (i->w)
+	test := make(map[int]map[int]bool)                 // u -> ítems de test
+	for u, lst := range users {
+		tr := make(map[int]float64, len(lst))
+		if len(lst) < 2 {
+			for _, x := range lst {
+				tr[x.i] = x.r
+			}
+			train[u] = tr
+			continue
+		}
+		perm := rand.Perm(len(lst))
+		szTest := int(math.Max(1, math.Round(opt.TestRatio*float64(len(lst)))))
+		if szTest >= len(lst) {
+			szTest = len(lst) - 1
+		}
+		te := make(map[int]bool, szTest)
+		for k, idx := range perm {
+			if k < szTest {
+				te[lst[idx].i] = true
+			} else {
+				tr[lst[idx].i] = lst[idx].r
+			}
+		}
+		train[u], test[u] = tr, te
+	}
+	tSplit := time.Since(s0)
+
+	// -------------------------------------------------------------------------
+	// 4) Ranking Top-K y métricas
+	// -------------------------------------------------------------------------
+	p0 := time.Now()
+	evalUsers := make([]int, 0, len(test))
+	for u := range test {
+		evalUsers = append(evalUsers, u)
+	}
+	sort.Ints(evalUsers)
+
+	var sumPrec, sumRec, sumNDCG float64
+	var usersHit, nHits int
+	recommended := make(map[int]bool)
+	topByUser := make(map[int][]scored, exampleUsers)
+	score := make(map[int]float64)
+	for _, u := range evalUsers {
+		clear(score)
+		seen := train[u]
+		if model == "user" {
+			nu := sim[u]
+			if kEval > 0 && len(nu) > kEval {
+				nu = nu[:kEval]
+			}
+			for _, e := range nu {
+				for i, w := range train[e.to] {
+					if _, ok := seen[i]; !ok {
+						score[i] += e.w * w
+					}
+				}
+			}
+		} else {
+			for j, w := range seen {
+				nj := sim[j]
+				if kEval > 0 && len(nj) > kEval {
+					nj = nj[:kEval]
+				}
+				for _, e := range nj {
+					if _, ok := seen[e.to]; !ok {
+						score[e.to] += e.w * w
+					}
+				}
+			}
+		}
+		top := topK(score, kMetrics)
+		if len(topByUser) < exampleUsers {
+			topByUser[u] = top
+		}
+
+		te := test[u]
+		hits, dcg := 0, 0.0
+		for rank, c := range top {
+			recommended[c.i] = true
+			if te[c.i] {
+				hits++
+				dcg += 1 / math.Log2(float64(rank)+2)
+			}
+		}
+		idcg := 0.0
+		for rank := 0; rank < min(kMetrics, len(te)); rank++ {
+			idcg += 1 / math.Log2(float64(rank)+2)
+		}
+		if hits > 0 {
+			usersHit++
+		}
+		nHits += hits
+		sumPrec += float64(hits) / float64(kMetrics)
+		sumRec += float64(hits) / float64(len(te))
+		sumNDCG += dcg / idcg
+	}
+	tPredict := time.Since(p0)
+
+	n := len(evalUsers)
+	var precK, recK, ndcgK, hitRateK, coverage float64
+	if n > 0 {
+		precK, recK = sumPrec/float64(n), sumRec/float64(n)
+		ndcgK, hitRateK = sumNDCG/float64(n), float64(usersHit)/float64(n)
+	}
+	if len(items) > 0 {
+		coverage = float64(len(recommended)) / float64(len(items))
+	}
+	throughput := float64(n) / tPredict.Seconds() // usuarios/s
+	tTotal := time.Since(t0)
+
+	// -------------------------------------------------------------------------
+	// 5) Consola
+	// -------------------------------------------------------------------------
+	weight := fmt.Sprintf("1 si rating >= %g", imp.Threshold)
+	if imp.Mode == preprocess.ImplicitConfidence {
+		weight = fmt.Sprintf("1 + %g·rating", imp.Alpha)
+	}
+	fmt.Printf("[MODEL=%s IMPLICIT %s] users=%d  interacciones=%d\n",
+		strings.ToUpper(model), imp.Mode, n, nInter)
+	fmt.Printf("Top-K metrics (K=%d):  Precision@K=%.4f  Recall@K=%.4f  NDCG@K=%.4f  HitRate@K=%.4f  Coverage=%.4f\n",
+		kMetrics, precK, recK, ndcgK, hitRateK, coverage)
+	fmt.Printf("Times: load_ratings=%s  load_sim=%s  split=%s  rank=%s  TOTAL=%s\n",
+		tLoadRatings, tLoadSim, tSplit, tPredict, tTotal)
+	fmt.Printf("Throughput: %.0f users/s (k_eval=%d)\n", throughput, kEval)
+
+	// -------------------------------------------------------------------------
+	// 6) Reporte
+	// -------------------------------------------------------------------------
+	rep := fmt.Sprintf(
+		`== RECOMMEND + EVAL IMPLICIT (%s) ==
+Sim CSV          : %s
+Ratings          : %s
+Implicit         : %s (peso %s)
+test_ratio       : %.2f
+k_eval           : %d
+k_metrics        : %d
+
+Interacciones    : %d
+Evaluated users  : %d
+Aciertos         : %d
+
+Top-K metrics (por usuario, relevantes = ítems de test):
+  Precision@K    : %.4f
+  Recall@K       : %.4f
+  NDCG@K         : %.4f
+  HitRate@K      : %.4f
+  Cobertura      : %.4f
+
+Throughput       : %.0f users/s
+
+Tiempos:
+  Cargar ratings : %s
+  Cargar sim     : %s
+  Split hold-out : %s
+  Rankear        : %s
+  TOTAL          : %s
+`,
+		strings.ToUpper(model), simPath, ratingsSrc, imp.Mode, weight,
+		opt.TestRatio, kEval, kMetrics,
+		nInter, n, nHits,
+		precK, recK, ndcgK, hitRateK, coverage,
+		throughput,
+		tLoadRatings, tLoadSim, tSplit, tPredict, tTotal,
+	)
+	if opt.Items != "" {
+		cat, err := catalog.Load(opt.Items)
+		if err != nil {
+			return err
+		}
+		rep += "\n" + implicitExamples(topByUser, test, cat, exampleItems)
+	}
+	_ = os.WriteFile(reportPath, []byte(rep), 0o644)
+
+	jr := report.New("recommend", opt)
+	jr.Count("evaluated_users", int64(n))
+	jr.Count("interactions", int64(nInter))
+	jr.Count("hits", int64(nHits))
+	jr.Count("users", int64(len(users)))
+	jr.Count("items", int64(len(items)))
+	jr.Metric("precision_at_k", precK)
+	jr.Metric("recall_at_k", recK)
+	jr.Metric("ndcg_at_k", ndcgK)
+	jr.Metric("hit_rate_at_k", hitRateK)
+	jr.Metric("coverage", coverage)
+	jr.Metric("throughput_users_per_sec", throughput)
+	jr.Time("load_ratings", tLoadRatings)
+	jr.Time("load_sim", tLoadSim)
+	jr.Time("split", tSplit)
+	jr.Time("rank", tPredict)
+	jr.Time("total", tTotal)
+	jr.Input("ratings", ratingsSrc)
+	jr.Input("sim", simPath)
+	jr.Input("implicit", l.Implicit())
+	jr.Set("implicit_mode", imp.Mode)
+	jr.Output("report", reportPath)
+	if err := jr.Write(report.Path(reportPath)); err != nil {
+		return err
+	}
+	fmt.Printf("Reporte -> %s\n", reportPath)
+	return nil
+}
+
+// topK devuelve los k ítems de mayor puntaje (> 0), empates por índice.
+func topK(score map[int]float64, k int) []scored {
+	out := make([]scored, 0, len(score))
+	for i, s := range score {
+		if s > 0 {
+			out = append(out, scored{i, s})
+		}
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].score != out[b].score {
+			return out[a].score > out[b].score
+		}
+		return out[a].i < out[b].i
+	})
+	return out[:min(k, len(out))]
+}
+
+// implicitExamples lista las primeras recomendaciones de algunos usuarios,
+// marcando con * las que están en su test.
+func implicitExamples(top map[int][]scored, test map[int]map[int]bool, cat *catalog.Catalog, nItems int) string {
+	us := make([]int, 0, len(top))
+	for u := range top {
+		us = append(us, u)
+	}
+	sort.Ints(us)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ejemplos (Top recomendado; * = en test):\n")
+	for _, u := range us {
+		fmt.Fprintf(&b, "  uIdx=%d (%d en test)\n", u, len(test[u]))
+		for _, c := range top[u][:min(nItems, len(top[u]))] {
+			mark := " "
+			if test[u][c.i] {
+				mark = "*"
+			}
+			fmt.Fprintf(&b, "  %s score=%.3f  %s\n", mark, c.score, cat.Label(c.i))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,826 @@
+package recommend
+
+/*
+RECOMMEND + EVALUATION (secuencial, con cronometraje y métricas top-K)
+
+- Split hold-out por usuario (test_ratio).
+- Predice con:
+    * user-based  (usa user_topk_*.csv y user_means.csv; ratings centrados,
+                   o en z-score / percentil con --norm)
+    * item-based  (usa item_topk_*.csv; centrado opcional con --centered)
+- Calcula:
+    * MAE y RMSE (error de predicción)
+    * Precision@K, Recall@K, NDCG@K, HitRate@K (métricas top-K por usuario)
+- Mide tiempos por fase y escribe un reporte en <artifacts>/reports/.
+
+Entradas:
+  - <artifacts>/ratings_ui.bin  (o ratings_ui.csv si no existe; ver internal/ratings)
+  - <artifacts>/sim/user_topk_*.csv   o   <artifacts>/sim/item_topk_*.csv
+  - <artifacts>/user_means.csv  (solo para model=user sin --baseline)
+  - <artifacts>/user_stds.csv o user_ranks.csv (solo model=user con --norm=zscore|percentile)
+  - <artifacts>/baseline.json, user_bias.csv, item_bias.csv (solo con --baseline)
+  - <artifacts>/implicit.json (solo con --implicit)
+  - <artifacts>/index/items.csv (opcional, de `pc3 movies`: títulos en los ejemplos del reporte)
+
+Flags:
+  --model=user|item
+  --sim=path/to/sim.csv  (un nombre sin directorio se busca en <artifacts>/sim/)
+  --test_ratio=0.1
+  --k_eval=0        (si >0, límite de vecinos de similitud a usar en la predicción)
+  --k_metrics=20    (K para métricas top-K: Precision@K, Recall@K, NDCG@K, HitRate@K)
+  --rel_th=4.0      (rating mínimo para considerar un ítem relevante)
+  --centered=false  (solo model=item; true si las similitudes se calcularon sobre ratings centrados)
+  --norm=center     (solo model=user; la normalización de normalize --norm con la que se
+                     calcularon las similitudes: center | zscore | percentile)
+  --baseline=false  (predice b_ui + Σ s·(r - b)/Σ|s| con b = μ + b_u + b_i de normalize --axis=baseline;
+                     pensado para similitudes de sim --input=baseline_csr)
+  --implicit=false  (feedback implícito de normalize --axis=implicit: rankea los ítems no vistos por
+                     Σ s·w y evalúa solo Precision/Recall/NDCG/HitRate@K; ver implicit.go)
+  --report=""       (ruta opcional; por defecto <artifacts>/reports/recommend_<model>[_implicit].txt)
+
+Junto al reporte de texto se escribe <reporte>.json (ver internal/report).
+*/
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/catalog"
+	"pc3/internal/layout"
+	"pc3/internal/preprocess"
+	"pc3/internal/ratings"
+	"pc3/internal/report"
+)
+
+type edge struct {
+	to int
+	w  float64
+}
+
+type ur struct {
+	i int
+	r float64
+} // ratings por usuario
+
+type ir struct {
+	u int
+	r float64
+} // ratings por item
+
+// para métricas top-K
+type evalRec struct {
+	i     int
+	rTrue float64
+	rPred float64
+}
+
+// Options son los flags de la etapa.
+type Options struct {
+	Model     string  `json:"model"`      // user | item
+	Sim       string  `json:"sim"`        // CSV de similitud (nombre o ruta)
+	TestRatio float64 `json:"test_ratio"` // proporción de test por usuario
+	KEval     int     `json:"k_eval"`     // si >0, límite de vecinos al predecir
+	KMetrics  int     `json:"k_metrics"`  // K para métricas top-K
+	RelTh     float64 `json:"rel_th"`     // rating mínimo para considerar un ítem relevante
+	Centered  bool    `json:"centered"`   // solo model=item
+	Norm      string  `json:"norm"`       // solo model=user: center | zscore | percentile
+	Baseline  bool    `json:"baseline"`   // residuos sobre μ + b_u + b_i (ver baseline.json)
+	Implicit  bool    `json:"implicit"`   // feedback implícito: solo métricas de ranking (ver implicit.go)
+	Report    string  `json:"-"`          // ruta de reporte (opcional)
+	Items     string  `json:"-"`          // catálogo index/items.csv (opcional): títulos en los ejemplos
+}
+
+// DefaultReport es el nombre del reporte sin --report:
+// recommend_<model>.txt, o recommend_<model>_implicit.txt con --implicit.
+func DefaultReport(opt Options) string {
+	if opt.Implicit {
+		return fmt.Sprintf("recommend_%s_implicit.txt", opt.Model)
+	}
+	return fmt.Sprintf("recommend_%s.txt", opt.Model)
+}
+
+// Run evalúa el modelo con un split hold-out y escribe el reporte.
+func Run(l layout.Layout, opt Options) error {
+	model, testRatio := opt.Model, opt.TestRatio
+	kEval, kMetrics, relTh, centered := opt.KEval, opt.KMetrics, opt.RelTh, opt.Centered
+	if model != "user" && model != "item" {
+		return fmt.Errorf("--model debe ser user o item (recibido %q)", model)
+	}
+	if opt.Sim == "" {
+		return errors.New("--sim requerido (ruta a user_topk_*.csv o item_topk_*.csv)")
+	}
+	if opt.Norm == "" {
+		opt.Norm = preprocess.NormCenter
+	}
+	if !preprocess.ValidNorm(opt.Norm) {
+		return fmt.Errorf("--norm debe ser center, zscore o percentile (recibido %q)", opt.Norm)
+	}
+	if opt.Norm != preprocess.NormCenter && (model != "user" || opt.Baseline) {
+		return fmt.Errorf("--norm=%s solo aplica a model=user sin --baseline", opt.Norm)
+	}
+	simPath, reportPath := l.SimFile(opt.Sim), opt.Report
+	userMeansPath := l.Means("user")
+	if reportPath == "" {
+		reportPath = filepath.Join(l.ReportsDir(), DefaultReport(opt))
+	}
+	_ = os.MkdirAll(filepath.Dir(reportPath), 0o755)
+	if opt.Implicit {
+		if opt.Baseline || centered || opt.Norm != preprocess.NormCenter {
+			return errors.New("--implicit no admite --baseline, --centered ni --norm")
+		}
+		return runImplicit(l, opt, simPath, reportPath)
+	}
+
+	t0 := time.Now()
+
+	// -------------------------------------------------------------------------
+	// 1) Cargar ratings en memoria
+	// -------------------------------------------------------------------------
+	users := make(map[int][]ur) // u -> [(i,r)]
+	items := make(map[int][]ir) // i -> [(u,r)]
+
+	ratingsSrc, err := loadRatings(l, users, items)
+	if err != nil {
+		return err
+	}
+	tLoadRatings := time.Since(t0)
+
+	// -------------------------------------------------------------------------
+	// 2) Cargar similitudes
+	// -------------------------------------------------------------------------
+	sim, err := loadSim(simPath)
+	if err != nil {
+		return err
+	}
+	tLoadSim := time.Since(t0) - tLoadRatings
+
+	// -------------------------------------------------------------------------
+	// 3) Medias de usuario (solo model=user) o sesgos del baseline
+	// -------------------------------------------------------------------------
+	means := make(map[int]float64)
+	un := &userNorm{norm: opt.Norm, means: means}
+	var base *baseline
+	var tLoadMeans time.Duration
+	if opt.Baseline {
+		m0 := time.Now()
+		if base, err = loadBaseline(l); err != nil {
+			return err
+		}
+		tLoadMeans = time.Since(m0)
+	} else if model == "user" {
+		m0 := time.Now()
+		mf, err := os.Open(userMeansPath)
+		if err != nil {
+			return err
+		}
+		mr := csv.NewReader(bufio.NewReader(mf))
+		_, _ = mr.Read()
+		for {
+			rec, err := mr.Read()
+			if err != nil {
+				if err.Error() == "EOF" {
+					break
+				}
+				continue
+			}
+			u, _ := strconv.Atoi(rec[0])
+			m, _ := strconv.ParseFloat(rec[1], 64)
+			means[u] = m
+		}
+		mf.Close()
+		if err := un.load(l); err != nil {
+			return err
+		}
+		tLoadMeans = time.Since(m0)
+	}
+
+	// -------------------------------------------------------------------------
+	// 4) Split hold-out por usuario
+	// -------------------------------------------------------------------------
+	s0 := time.Now()
+	rand.Seed(time.Now().UnixNano())
+
+	type testPair struct {
+		u, i int
+		r    float64
+	}
+	var test []testPair
+	train := make(map[int]map[int]float64) // u -> (i->r)
+
+	for u, lst := range users {
+		if len(lst) < 2 {
+			continue
+		} // necesita al menos 2 para train/test
+		perm := rand.Perm(len(lst))
+		szTest := int(math.Max(1, math.Round(testRatio*float64(len(lst)))))
+		if szTest >= len(lst) {
+			szTest = len(lst) - 1
+		}
+		tr := make(map[int]float64, len(lst)-szTest)
+		for k, idx := range perm {
+			it := lst[idx]
+			if k < szTest {
+				test = append(test, testPair{u: u, i: it.i, r: it.r})
+			} else {
+				tr[it.i] = it.r
+			}
+		}
+		train[u] = tr
+	}
+	tSplit := time.Since(s0)
+
+	// -------------------------------------------------------------------------
+	// 5) Predicción y métricas de error (MAE, RMSE)
+	//    + recopilación de datos para métricas top-K
+	// -------------------------------------------------------------------------
+	p0 := time.Now()
+	var absSum, sqSum float64
+	var n int
+
+	evalByUser := make(map[int][]evalRec) // u -> lista de (i, rTrue, rPred)
+
+	for _, t := range test {
+		var pred float64
+
+		if base != nil {
+			// BASELINE: las similitudes se calcularon sobre residuos r - b,
+			// así que se promedian residuos y se suma b_ui.
+			//   user: vecinos v de u que puntuaron i; item: ítems j de u en train
+			var num, den float64
+			if model == "user" {
+				nu := sim[t.u]
+				if kEval > 0 && len(nu) > kEval {
+					nu = nu[:kEval]
+				}
+				for _, e := range nu {
+					rv := ratingFromList(items[t.i], e.to)
+					if rv <= 0 {
+						continue
+					}
+					num += e.w * (rv - base.predict(e.to, t.i))
+					den += math.Abs(e.w)
+				}
+			} else {
+				ni := sim[t.i]
+				if kEval > 0 && len(ni) > kEval {
+					ni = ni[:kEval]
+				}
+				uj := train[t.u]
+				for _, e := range ni {
+					if rj, ok := uj[e.to]; ok {
+						num += e.w * (rj - base.predict(t.u, e.to))
+						den += math.Abs(e.w)
+					}
+				}
+			}
+			pred = base.predict(t.u, t.i)
+			if den > 0 {
+				pred += num / den
+			}
+			pred = clamp(pred, 0.5, 5.0)
+		} else if model == "user" {
+			// USER-BASED: se asume que sim se calculó sobre ratings normalizados
+			// (Pearson o Cosine sobre matrix_user_csr con el mismo --norm)
+			nu := sim[t.u]
+			if kEval > 0 && len(nu) > kEval {
+				nu = nu[:kEval]
+			}
+			var num, den float64
+			for _, e := range nu {
+				rv := ratingFromList(items[t.i], e.to) // rating de vecino e.to sobre item i
+				if rv <= 0 {
+					continue
+				}
+				num += e.w * un.normalize(e.to, rv)
+				den += math.Abs(e.w)
+			}
+			if den == 0 {
+				pred = means[t.u]
+			} else {
+				pred = un.denormalize(t.u, num/den)
+			}
+			pred = clamp(pred, 0.5, 5.0)
+		} else {
+			// ITEM-BASED: podemos usar fórmula centrada o no centrada
+			ni := sim[t.i]
+			if kEval > 0 && len(ni) > kEval {
+				ni = ni[:kEval]
+			}
+			uj := train[t.u] // ratings de u en train
+
+			if centered {
+				// similitudes calculadas sobre ratings centrados:
+				//   r'_u,j = r_u,j - mean_u
+				// pred = mean_u + sum_j sim(i,j)*(r_u,j - mean_u) / sum_j |sim(i,j)|
+				meanU := meanMap(uj)
+				var num, den float64
+				for _, e := range ni {
+					if rj, ok := uj[e.to]; ok {
+						num += e.w * (rj - meanU)
+						den += math.Abs(e.w)
+					}
+				}
+				if den == 0 {
+					pred = meanU
+				} else {
+					pred = meanU + num/den
+				}
+			} else {
+				// similitudes calculadas sobre ratings sin centrar (por ejemplo Cosine/Jaccard item)
+				// pred = sum_j sim(i,j)*r_u,j / sum_j |sim(i,j)|
+				var num, den float64
+				for _, e := range ni {
+					if rj, ok := uj[e.to]; ok {
+						num += e.w * rj
+						den += math.Abs(e.w)
+					}
+				}
+				if den == 0 {
+					pred = meanMap(uj)
+				} else {
+					pred = num / den
+				}
+			}
+
+			pred = clamp(pred, 0.5, 5.0)
+		}
+
+		err := t.r - pred
+		absSum += math.Abs(err)
+		sqSum += err * err
+		n++
+
+		// guardar para métricas top-K
+		evalByUser[t.u] = append(evalByUser[t.u], evalRec{
+			i:     t.i,
+			rTrue: t.r,
+			rPred: pred,
+		})
+	}
+	tPredict := time.Since(p0)
+
+	mae := absSum / float64(n)
+	rmse := math.Sqrt(sqSum / float64(n))
+	throughput := float64(n) / tPredict.Seconds() // preds/s
+
+	tTotal := time.Since(t0)
+
+	// -------------------------------------------------------------------------
+	// 6) Métricas top-K (Precision@K, Recall@K, NDCG@K, HitRate@K)
+	// -------------------------------------------------------------------------
+	precK, recK, ndcgK, hitRateK := computeTopKMetrics(evalByUser, kMetrics, relTh)
+
+	// -------------------------------------------------------------------------
+	// 7) Consola
+	// -------------------------------------------------------------------------
+	fmt.Printf("[MODEL=%s] eval=%d  MAE=%.4f  RMSE=%.4f\n",
+		strings.ToUpper(model), n, mae, rmse)
+	fmt.Printf("Top-K metrics (K=%d, rel>=%.1f):  Precision@K=%.4f  Recall@K=%.4f  NDCG@K=%.4f  HitRate@K=%.4f\n",
+		kMetrics, relTh, precK, recK, ndcgK, hitRateK)
+	fmt.Printf("Times: load_ratings=%s  load_sim=%s  load_means=%s  split=%s  predict=%s  TOTAL=%s\n",
+		tLoadRatings, tLoadSim, tLoadMeans, tSplit, tPredict, tTotal)
+	fmt.Printf("Throughput: %.0f preds/s (k_eval=%d)\n", throughput, kEval)
+
+	// -------------------------------------------------------------------------
+	// 8) Reporte
+	// -------------------------------------------------------------------------
+	rep := fmt.Sprintf(
+		`== RECOMMEND + EVAL (%s) ==
+Sim CSV          : %s
+Ratings          : %s
+User means       : %v
+Norm (user)      : %s
+Baseline         : %v
+test_ratio       : %.2f
+k_eval           : %d
+k_metrics        : %d
+rel_threshold    : %.2f
+centered (item)  : %v
+
+Evaluated pairs  : %d
+MAE              : %.4f
+RMSE             : %.4f
+
+Top-K metrics (por usuario):
+  Precision@K    : %.4f
+  Recall@K       : %.4f
+  NDCG@K         : %.4f
+  HitRate@K      : %.4f
+
+Throughput       : %.0f preds/s
+
+Tiempos:
+  Cargar ratings : %s
+  Cargar sim     : %s
+  Cargar medias  : %s
+  Split hold-out : %s
+  Predecir       : %s
+  TOTAL          : %s
+`,
+		strings.ToUpper(model), simPath, ratingsSrc, model == "user" && base == nil, opt.Norm, opt.Baseline,
+		testRatio, kEval, kMetrics, relTh, centered,
+		n, mae, rmse,
+		precK, recK, ndcgK, hitRateK,
+		throughput,
+		tLoadRatings, tLoadSim, tLoadMeans, tSplit, tPredict, tTotal,
+	)
+
+	if opt.Items != "" {
+		cat, err := catalog.Load(opt.Items)
+		if err != nil {
+			return err
+		}
+		rep += "\n" + examples(evalByUser, cat, exampleUsers, exampleItems)
+	}
+
+	_ = os.WriteFile(reportPath, []byte(rep), 0o644)
+
+	jr := report.New("recommend", opt)
+	jr.Count("evaluated", int64(n))
+	jr.Count("users", int64(len(users)))
+	jr.Count("items", int64(len(items)))
+	jr.Metric("mae", mae)
+	jr.Metric("rmse", rmse)
+	jr.Metric("precision_at_k", precK)
+	jr.Metric("recall_at_k", recK)
+	jr.Metric("ndcg_at_k", ndcgK)
+	jr.Metric("hit_rate_at_k", hitRateK)
+	jr.Metric("throughput_preds_per_sec", throughput)
+	jr.Time("load_ratings", tLoadRatings)
+	jr.Time("load_sim", tLoadSim)
+	jr.Time("load_means", tLoadMeans)
+	jr.Time("split", tSplit)
+	jr.Time("predict", tPredict)
+	jr.Time("total", tTotal)
+	jr.Input("ratings", ratingsSrc)
+	jr.Input("sim", simPath)
+	if un.table != "" {
+		jr.Input("user_"+opt.Norm, un.table)
+	}
+	if base != nil {
+		jr.Input("baseline", l.Baseline())
+		jr.Metric("global_mean", base.Mu)
+	}
+	jr.Output("report", reportPath)
+	if err := jr.Write(report.Path(reportPath)); err != nil {
+		return err
+	}
+	fmt.Printf("Reporte -> %s\n", reportPath)
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// helpers
+// -----------------------------------------------------------------------------
+
+// Cantidad de usuarios e ítems que muestra la sección de ejemplos.
+const (
+	exampleUsers = 3
+	exampleItems = 5
+)
+
+// examples lista, para los primeros usuarios evaluados, sus ítems de test
+// ordenados por predicción, con título y rating real.
+func examples(evalByUser map[int][]evalRec, cat *catalog.Catalog, nUsers, nItems int) string {
+	us := make([]int, 0, len(evalByUser))
+	for u := range evalByUser {
+		us = append(us, u)
+	}
+	sort.Ints(us)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Ejemplos (ítems de test por predicción):\n")
+	for _, u := range us[:min(nUsers, len(us))] {
+		recs := append([]evalRec(nil), evalByUser[u]...)
+		sort.Slice(recs, func(a, c int) bool { return recs[a].rPred > recs[c].rPred })
+		fmt.Fprintf(&b, "  uIdx=%d\n", u)
+		for _, e := range recs[:min(nItems, len(recs))] {
+			fmt.Fprintf(&b, "    pred=%.2f  real=%.1f  %s\n", e.rPred, e.rTrue, cat.Label(e.i))
+		}
+	}
+	return b.String()
+}
+
+// loadSim lee un CSV de similitud Top-K: nodo -> vecinos en el orden del
+// archivo (ya ordenados por similitud).
+func loadSim(path string) (map[int][]edge, error) {
+	sim := make(map[int][]edge)
+	sf, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer sf.Close()
+	sr := csv.NewReader(bufio.NewReader(sf))
+	_, _ = sr.Read() // header
+	for {
+		rec, err := sr.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		a, _ := strconv.Atoi(rec[0])
+		b, _ := strconv.Atoi(rec[1])
+		w, _ := strconv.ParseFloat(rec[2], 64)
+		sim[a] = append(sim[a], edge{to: b, w: w})
+	}
+	return sim, nil
+}
+
+// loadRatings llena users/items desde ratings_ui.bin si existe (columnas
+// binarias de remap) o, si no, desde ratings_ui.csv. Devuelve la ruta usada.
+func loadRatings(l layout.Layout, users map[int][]ur, items map[int][]ir) (string, error) {
+	if binPath := l.TripletsBin(); exists(binPath) {
+		t, err := ratings.ReadBinary(binPath, false)
+		if err != nil {
+			return "", err
+		}
+		for p := range t.U {
+			u, i, r := int(t.U[p]), int(t.I[p]), float64(t.R[p])
+			users[u] = append(users[u], ur{i, r})
+			items[i] = append(items[i], ir{u, r})
+		}
+		return binPath, nil
+	}
+
+	path := l.Triplets()
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReader(f))
+	_, _ = rd.Read() // header
+	for {
+		rec, err := rd.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		u, _ := strconv.Atoi(rec[0])
+		i, _ := strconv.Atoi(rec[1])
+		r, _ := strconv.ParseFloat(rec[2], 64)
+		users[u] = append(users[u], ur{i, r})
+		items[i] = append(items[i], ir{u, r})
+	}
+	return path, nil
+}
+
+// userNorm traduce ratings a la escala en la que se calcularon las
+// similitudes user-based (--norm) y de vuelta.
+type userNorm struct {
+	norm  string
+	means map[int]float64
+	stds  []float64              // zscore
+	ranks []preprocess.RankTable // percentile
+	table string                 // archivo cargado ("" con center)
+}
+
+func (n *userNorm) load(l layout.Layout) error {
+	var err error
+	switch n.norm {
+	case preprocess.NormZScore:
+		n.table = l.Stds("user")
+		n.stds, err = loadDense(n.table)
+	case preprocess.NormPercentile:
+		n.table = l.Ranks("user")
+		n.ranks, err = preprocess.LoadRanks(n.table)
+	}
+	if err != nil {
+		return fmt.Errorf("--norm=%s requiere normalize --norm=%s: %w", n.norm, n.norm, err)
+	}
+	return nil
+}
+
+// normalize lleva el rating r del usuario v a la escala normalizada.
+func (n *userNorm) normalize(v int, r float64) float64 {
+	switch n.norm {
+	case preprocess.NormZScore:
+		if v >= len(n.stds) || n.stds[v] == 0 {
+			return 0
+		}
+		return (r - n.means[v]) / n.stds[v]
+	case preprocess.NormPercentile:
+		if v >= len(n.ranks) {
+			return 0
+		}
+		return n.ranks[v].Pct(r) - 0.5
+	}
+	return r - n.means[v]
+}
+
+// denormalize convierte el promedio ponderado z de los vecinos en un
+// rating en la escala de u: μ_u + z, μ_u + σ_u·z o el rating de u en el
+// percentil 0.5 + z.
+func (n *userNorm) denormalize(u int, z float64) float64 {
+	switch n.norm {
+	case preprocess.NormZScore:
+		if u >= len(n.stds) {
+			return n.means[u]
+		}
+		return n.means[u] + n.stds[u]*z
+	case preprocess.NormPercentile:
+		if u >= len(n.ranks) || len(n.ranks[u].R) == 0 {
+			return n.means[u]
+		}
+		return n.ranks[u].Rating(clamp(0.5+z, 0, 1))
+	}
+	return n.means[u] + z
+}
+
+// baseline son μ y los sesgos de normalize --axis=baseline.
+type baseline struct {
+	preprocess.Baseline
+	bu, bi []float64
+}
+
+// predict devuelve b_ui = μ + b_u + b_i (sesgo 0 fuera de rango).
+func (b *baseline) predict(u, i int) float64 {
+	p := b.Mu
+	if u < len(b.bu) {
+		p += b.bu[u]
+	}
+	if i < len(b.bi) {
+		p += b.bi[i]
+	}
+	return p
+}
+
+func loadBaseline(l layout.Layout) (*baseline, error) {
+	js, err := os.ReadFile(l.Baseline())
+	if err != nil {
+		return nil, fmt.Errorf("--baseline requiere normalize --axis=baseline: %w", err)
+	}
+	b := &baseline{}
+	if err := json.Unmarshal(js, &b.Baseline); err != nil {
+		return nil, fmt.Errorf("%s: %w", l.Baseline(), err)
+	}
+	if b.bu, err = loadDense(l.Bias("user")); err != nil {
+		return nil, err
+	}
+	if b.bi, err = loadDense(l.Bias("item")); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// loadDense lee un CSV idx,valor como slice indexado por idx.
+func loadDense(path string) ([]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	rd := csv.NewReader(bufio.NewReader(f))
+	_, _ = rd.Read() // header
+	var out []float64
+	for {
+		rec, err := rd.Read()
+		if err != nil {
+			if err.Error() == "EOF" {
+				break
+			}
+			continue
+		}
+		k, _ := strconv.Atoi(rec[0])
+		v, _ := strconv.ParseFloat(rec[1], 64)
+		for len(out) <= k {
+			out = append(out, 0)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func ratingFromList(lst []ir, u int) float64 {
+	for _, x := range lst {
+		if x.u == u {
+			return x.r
+		}
+	}
+	return 0
+}
+
+func clamp(x, a, b float64) float64 {
+	if x < a {
+		return a
+	}
+	if x > b {
+		return b
+	}
+	return x
+}
+
+func meanMap(m map[int]float64) float64 {
+	if len(m) == 0 {
+		return 3.0
+	}
+	var s float64
+	for _, v := range m {
+		s += v
+	}
+	return s / float64(len(m))
+}
+
+// computeTopKMetrics calcula Precision@K, Recall@K, NDCG@K y HitRate@K
+// promediando sobre usuarios.
+func computeTopKMetrics(evalByUser map[int][]evalRec, k int, relTh float64) (precK, recK, ndcgK, hitRateK float64) {
+	if k <= 0 {
+		return 0, 0, 0, 0
+	}
+
+	var sumPrec, sumRec, sumNDCG float64
+	var usersWithRel, usersTotal, usersHit int
+
+	for _, lst := range evalByUser {
+		if len(lst) == 0 {
+			continue
+		}
+		usersTotal++
+
+		// contar relevantes totales
+		totalRel := 0
+		for _, e := range lst {
+			if e.rTrue >= relTh {
+				totalRel++
+			}
+		}
+		if totalRel == 0 {
+			continue
+		}
+		usersWithRel++
+
+		// ordenar por predicción descendente
+		sort.Slice(lst, func(i, j int) bool { return lst[i].rPred > lst[j].rPred })
+
+		kEff := k
+		if len(lst) < kEff {
+			kEff = len(lst)
+		}
+
+		relInTop := 0
+		dcg := 0.0
+		for rank := 0; rank < kEff; rank++ {
+			if lst[rank].rTrue >= relTh {
+				relInTop++
+				gain := 1.0
+				den := math.Log2(float64(rank) + 2.0) // log2(rank+2)
+				dcg += gain / den
+			}
+		}
+
+		if relInTop > 0 {
+			usersHit++
+		}
+
+		prec := float64(relInTop) / float64(kEff)
+		rec := float64(relInTop) / float64(totalRel)
+
+		// IDCG
+		maxRank := kEff
+		if totalRel < maxRank {
+			maxRank = totalRel
+		}
+		idcg := 0.0
+		for rank := 0; rank < maxRank; rank++ {
+			idcg += 1.0 / math.Log2(float64(rank)+2.0)
+		}
+		ndcg := 0.0
+		if idcg > 0 {
+			ndcg = dcg / idcg
+		}
+
+		sumPrec += prec
+		sumRec += rec
+		sumNDCG += ndcg
+	}
+
+	if usersWithRel > 0 {
+		precK = sumPrec / float64(usersWithRel)
+		recK = sumRec / float64(usersWithRel)
+		ndcgK = sumNDCG / float64(usersWithRel)
+	}
+	if usersTotal > 0 {
+		hitRateK = float64(usersHit) / float64(usersTotal)
+	}
+	return
+}
@@ -0,0 +1,94 @@
+package report
+
+/*
+REPORTES JSON (uno junto a cada reporte de texto)
+
+Cada etapa escribe, además de su .txt en español, un .json con la misma
+información en forma estructurada para dashboards y notebooks:
+
+  {
+    "stage": "remap",
+    "generated": "2024-05-01T12:00:00Z",
+    "params":  {...},            // opciones de la etapa
+    "counts":  {"users": 162541, ...},
+    "timings_sec": {"load": 1.25, ...},
+    "metrics": {"mae": 0.65, ...},
+    "inputs":  {"ratings": "artifacts/ratings_ui.bin", ...},
+    "outputs": {"triplets": "artifacts/ratings_ui.csv", ...},
+    "extra":   {...}             // secciones propias (rondas, géneros, ejemplos…)
+  }
+
+Las claves van en inglés y snake_case; las secciones vacías se omiten. El
+archivo se llama como el .txt con extensión .json (ver Path).
+*/
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Report es el contenido de un reporte JSON.
+type Report struct {
+	Stage     string             `json:"stage"`
+	Generated time.Time          `json:"generated"`
+	Params    any                `json:"params,omitempty"`
+	Counts    map[string]int64   `json:"counts,omitempty"`
+	Timings   map[string]float64 `json:"timings_sec,omitempty"`
+	Metrics   map[string]float64 `json:"metrics,omitempty"`
+	Inputs    map[string]string  `json:"inputs,omitempty"`
+	Outputs   map[string]string  `json:"outputs,omitempty"`
+	Extra     map[string]any     `json:"extra,omitempty"`
+}
+
+// New crea un reporte vacío de la etapa.
+func New(stage string, params any) *Report {
+	return &Report{
+		Stage:     stage,
+		Generated: time.Now().UTC().Round(time.Second),
+		Params:    params,
+		Counts:    map[string]int64{},
+		Timings:   map[string]float64{},
+		Metrics:   map[string]float64{},
+		Inputs:    map[string]string{},
+		Outputs:   map[string]string{},
+		Extra:     map[string]any{},
+	}
+}
+
+// Count registra un contador.
+func (r *Report) Count(name string, v int64) { r.Counts[name] = v }
+
+// Time registra la duración de una fase en segundos.
+func (r *Report) Time(name string, d time.Duration) { r.Timings[name] = d.Seconds() }
+
+// Metric registra una métrica.
+func (r *Report) Metric(name string, v float64) { r.Metrics[name] = v }
+
+// Input registra un archivo leído (cuando la etapa elige entre varios).
+func (r *Report) Input(name, path string) { r.Inputs[name] = path }
+
+// Output registra un archivo generado.
+func (r *Report) Output(name, path string) { r.Outputs[name] = path }
+
+// Set guarda una sección propia de la etapa.
+func (r *Report) Set(name string, v any) { r.Extra[name] = v }
+
+// Path es la ruta del JSON que acompaña al reporte de texto txt.
+func Path(txt string) string {
+	return strings.TrimSuffix(txt, filepath.Ext(txt)) + ".json"
+}
+
+// Write guarda el reporte con sangría.
+func (r *Report) Write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(b, '\n'), 0o644)
+}
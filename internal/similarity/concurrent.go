@@ -0,0 +1,262 @@
+package similarity
+
+/*
+Driver CONCURRENTE
+
+- Un productor reparte bloques de filas (cestas) por el canal jobs. Los
+  bloques se arman por costo (pares n·(n-1)/2 de cada cesta, ver jobRanges)
+  y se encolan del más caro al más barato: en mode=user las cestas son
+  ítems y uno popular tiene miles de usuarios, así que bloques de filas
+  fijas dejaban a un worker solo con los ítems más vistos al final.
+- Cada worker recorre los pares de sus cestas y los encola por shard en un
+  buffer local; al llenarse, toma el lock del shard UNA vez y vuelca el lote
+  (menos contención que un lock por par).
+- numShards shards globales con map[par]*Acc; el shard se elige por hash del
+  par canonizado ⇒ carga balanceada.
+- Métricas PairRanked: una segunda pasada igual a la primera vuelca los
+  valores de los pares candidatos en su shard, y cada shard los rankea al
+  finalizar (ver ranked.go).
+- Top-K: los shards se finalizan en paralelo; los heaps por nodo se protegen
+  con locks por franjas.
+*/
+
+import (
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pc3/internal/csr"
+)
+
+// potencia de 2 para usar & en vez de %
+const numShards = 64
+
+const (
+	rowsPerJob = 64   // máximo de cestas por trabajo
+	jobsPerWkr = 16   // trabajos por worker que apunta jobRanges
+	batchSize  = 1024 // updates por lote antes de volcar al shard
+	numStripes = 256  // locks de los heaps Top-K
+)
+
+type shard struct {
+	mu   sync.Mutex
+	m    map[uint64]*Acc
+	vals map[uint64]*pairVals // segunda pasada de las métricas PairRanked
+}
+
+type update struct {
+	key     uint64
+	x, y, w float64
+}
+
+func shardOf(key uint64) int {
+	return int((key * 0x9E3779B97F4A7C15) >> 58 & (numShards - 1))
+}
+
+func (s *shard) apply(sim Similarity, batch []update) {
+	s.mu.Lock()
+	for _, u := range batch {
+		t := s.m[u.key]
+		if t == nil {
+			t = &Acc{}
+			s.m[u.key] = t
+		}
+		sim.Update(t, u.x, u.y, u.w)
+	}
+	s.mu.Unlock()
+}
+
+// collect guarda en vals los valores de los pares candidatos del lote.
+func (s *shard) collect(batch []update, weighted bool) {
+	s.mu.Lock()
+	for _, u := range batch {
+		if v := s.vals[u.key]; v != nil {
+			v.add(u.x, u.y, u.w, weighted)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// RunConcurrent es el equivalente de Run con un pool de opt.Workers goroutines.
+func RunConcurrent(m *csr.Matrix, s Similarity, opt Options) (*Result, error) {
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	if opt.Workers <= 0 {
+		opt.Workers = runtime.NumCPU()
+	}
+	res := &Result{Metric: s.Name(), Options: opt, Concurrent: true, Shards: numShards, Directed: IsDirected(s)}
+
+	t0 := time.Now()
+	b, pctBasket, pctNode := baskets(m, opt)
+	weights := basketWeights(b, opt, pctBasket, pctNode)
+	norms := nodeNorms(b, s.Norm(), weights, pctBasket, pctNode)
+	t1 := time.Now()
+
+	var shards [numShards]*shard
+	for i := range shards {
+		shards[i] = &shard{m: make(map[uint64]*Acc)}
+	}
+
+	// pass reparte las cestas entre los workers y entrega a flush los pares
+	// de cada shard por lotes. Devuelve cestas, valores y pares recorridos.
+	pass := func(flush func(sh int, batch []update)) (nBaskets, nTriplets, nPairs uint64) {
+		jobs := make(chan [2]int, opt.Workers*4)
+		var wg sync.WaitGroup
+		wg.Add(opt.Workers)
+		for w := 0; w < opt.Workers; w++ {
+			go func() {
+				defer wg.Done()
+				var baskets, triplets, pairs uint64
+				pending := make([][]update, numShards)
+				buf := make([]entry, 0, 256)
+				for job := range jobs {
+					for r := job[0]; r < job[1]; r++ {
+						if !KeepByPct(r, pctBasket) {
+							continue
+						}
+						buf = basket(b, r, pctNode, buf[:0])
+						if len(buf) == 0 {
+							continue
+						}
+						baskets++
+						triplets += uint64(len(buf))
+						wb := weightOf(weights, r)
+						for a := 0; a < len(buf); a++ {
+							for c := a + 1; c < len(buf); c++ {
+								if buf[a].node == buf[c].node {
+									continue
+								}
+								key, x, y := canon(buf[a], buf[c])
+								sh := shardOf(key)
+								pending[sh] = append(pending[sh], update{key, x, y, wb})
+								if len(pending[sh]) >= batchSize {
+									flush(sh, pending[sh])
+									pending[sh] = pending[sh][:0]
+								}
+								pairs++
+							}
+						}
+					}
+				}
+				for sh, batch := range pending {
+					if len(batch) > 0 {
+						flush(sh, batch)
+					}
+				}
+				atomic.AddUint64(&nBaskets, baskets)
+				atomic.AddUint64(&nTriplets, triplets)
+				atomic.AddUint64(&nPairs, pairs)
+			}()
+		}
+		for _, job := range jobRanges(b, opt.Workers) {
+			jobs <- job
+		}
+		close(jobs)
+		wg.Wait()
+		return nBaskets, nTriplets, nPairs
+	}
+
+	res.Baskets, res.Triplets, res.Pairs = pass(func(sh int, batch []update) { shards[sh].apply(s, batch) })
+	if needsPairRanks(s) {
+		// segunda pasada: co-valorados de los pares candidatos (ver ranked.go);
+		// cada shard los rankea al finalizar
+		for _, sh := range shards {
+			sh.vals = candidates(sh.m, opt.MinCo)
+			res.Ranked += uint64(len(sh.vals))
+		}
+		pass(func(sh int, batch []update) { shards[sh].collect(batch, weights != nil) })
+	}
+	t2 := time.Now()
+
+	// ---- Top-K: shards en paralelo ----
+	top := newTopK(b.Cols(), opt.K)
+	var stripes [numStripes]sync.Mutex
+	offer := func(i, j int, sim float64) {
+		mu := &stripes[i&(numStripes-1)]
+		mu.Lock()
+		top.offer(i, j, sim)
+		mu.Unlock()
+	}
+	next := int32(-1)
+	var wg sync.WaitGroup
+	wg.Add(opt.Workers)
+	for w := 0; w < opt.Workers; w++ {
+		go func() {
+			defer wg.Done()
+			var kept uint64
+			for {
+				sh := int(atomic.AddInt32(&next, 1))
+				if sh >= numShards {
+					break
+				}
+				if shards[sh].vals != nil {
+					rerank(s, shards[sh].m, shards[sh].vals)
+					shards[sh].vals = nil
+				}
+				for key, t := range shards[sh].m {
+					i, j := splitKey(key)
+					ij, ji, okIJ, okJI := score(s, t, normOf(norms, i), normOf(norms, j), opt)
+					if okIJ {
+						offer(i, j, ij)
+					}
+					if okJI {
+						offer(j, i, ji)
+					}
+					if okIJ || okJI {
+						kept++
+					}
+				}
+			}
+			atomic.AddUint64(&res.Kept, kept)
+		}()
+	}
+	wg.Wait()
+	res.TopK = top.sorted()
+	t3 := time.Now()
+
+	res.TNorms, res.TAccumulate, res.TTopK = t1.Sub(t0), t2.Sub(t1), t3.Sub(t2)
+	return res, nil
+}
+
+// jobRanges parte las filas de b en rangos [lo, hi) de costo parecido: se
+// agregan filas hasta juntar ~1/(workers·jobsPerWkr) de los pares totales o
+// rowsPerJob filas, así una cesta enorme queda sola en su trabajo. Devuelve
+// los rangos del más caro al más barato para que los grandes empiecen
+// primero.
+func jobRanges(b *csr.Matrix, workers int) [][2]int {
+	pairs := func(r int) int64 {
+		n := int64(b.Indptr[r+1] - b.Indptr[r])
+		return n * (n - 1) / 2
+	}
+	var total int64
+	for r := 0; r < b.Rows(); r++ {
+		total += pairs(r)
+	}
+	target := max(total/int64(workers*jobsPerWkr), 1)
+
+	type job struct {
+		r    [2]int
+		cost int64
+	}
+	var out []job
+	cur := job{r: [2]int{0, 0}}
+	for r := 0; r < b.Rows(); r++ {
+		cur.r[1], cur.cost = r+1, cur.cost+pairs(r)
+		if cur.cost >= target || cur.r[1]-cur.r[0] >= rowsPerJob {
+			out = append(out, cur)
+			cur = job{r: [2]int{r + 1, r + 1}}
+		}
+	}
+	if cur.r[1] > cur.r[0] {
+		out = append(out, cur)
+	}
+	sort.SliceStable(out, func(a, c int) bool { return out[a].cost > out[c].cost })
+	ranges := make([][2]int, len(out))
+	for k, j := range out {
+		ranges[k] = j.r
+	}
+	return ranges
+}
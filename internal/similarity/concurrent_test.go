@@ -0,0 +1,81 @@
+package similarity
+
+import (
+	"math"
+	"testing"
+
+	"pc3/internal/csr"
+)
+
+// fixture arma una matriz usuario×ítem fija de 40×60: cada usuario califica
+// unos 20 ítems y hay ítems muy populares, así jobRanges parte las cestas en
+// bloques de costo distinto.
+func fixture(t *testing.T) *csr.Matrix {
+	t.Helper()
+	const users, items = 40, 60
+	indptr := []int64{0}
+	var indices []int32
+	var data []float32
+	for u := 0; u < users; u++ {
+		for i := 0; i < items; i++ {
+			if i < 5 || (u*7+i*13)%3 == 0 {
+				indices = append(indices, int32(i))
+				data = append(data, float32(1+(u*i+u+2*i)%5))
+			}
+		}
+		indptr = append(indptr, int64(len(indices)))
+	}
+	m, err := csr.New(csr.Meta{Items: items, Axis: csr.AxisUser}, indptr, indices, data)
+	if err != nil {
+		t.Fatalf("csr.New: %v", err)
+	}
+	return m
+}
+
+func TestRunConcurrentMatchesRunUserMode(t *testing.T) {
+	m := fixture(t)
+	for _, name := range Names() {
+		s, _ := ByName(name)
+		if IsDirected(s) {
+			continue // solo mode=item
+		}
+		t.Run(name, func(t *testing.T) {
+			// K mayor que la cantidad de usuarios: el Top-K no recorta y los
+			// empates no cambian qué vecinos quedan.
+			opt := Options{Mode: ModeUser, K: 64, MinCo: 2, PctUsers: 100, PctItems: 100, Workers: 4}
+			seq, err := Run(m, s, opt)
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			conc, err := RunConcurrent(m, s, opt)
+			if err != nil {
+				t.Fatalf("RunConcurrent: %v", err)
+			}
+			if seq.Kept == 0 {
+				t.Fatal("el fixture no dejó ningún par")
+			}
+			if seq.Pairs != conc.Pairs || seq.Kept != conc.Kept || seq.Baskets != conc.Baskets {
+				t.Fatalf("contadores: seq pairs=%d kept=%d baskets=%d, conc pairs=%d kept=%d baskets=%d",
+					seq.Pairs, seq.Kept, seq.Baskets, conc.Pairs, conc.Kept, conc.Baskets)
+			}
+			if len(seq.TopK) != len(conc.TopK) {
+				t.Fatalf("nodos: seq=%d conc=%d", len(seq.TopK), len(conc.TopK))
+			}
+			for u := range seq.TopK {
+				want := map[int]float64{}
+				for _, n := range seq.TopK[u] {
+					want[n.J] = n.S
+				}
+				if len(conc.TopK[u]) != len(want) {
+					t.Fatalf("usuario %d: seq %d vecinos, conc %d", u, len(want), len(conc.TopK[u]))
+				}
+				for _, n := range conc.TopK[u] {
+					s, ok := want[n.J]
+					if !ok || math.Abs(s-n.S) > 1e-9 {
+						t.Errorf("usuario %d, vecino %d: seq=%v (presente=%v) conc=%v", u, n.J, s, ok, n.S)
+					}
+				}
+			}
+		})
+	}
+}
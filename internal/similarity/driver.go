@@ -0,0 +1,331 @@
+package similarity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"pc3/internal/csr"
+)
+
+// Mode elige qué se compara.
+type Mode string
+
+const (
+	ModeItem Mode = "item" // similitud ítem-ítem (cestas = usuarios)
+	ModeUser Mode = "user" // similitud usuario-usuario (cestas = ítems)
+)
+
+// ParseMode valida el valor de --mode.
+func ParseMode(s string) (Mode, error) {
+	switch Mode(s) {
+	case ModeItem, ModeUser:
+		return Mode(s), nil
+	}
+	return "", fmt.Errorf("--mode debe ser user o item (recibido %q)", s)
+}
+
+// Options son los parámetros comunes a ambos drivers.
+type Options struct {
+	Mode     Mode `json:"mode"`
+	K        int  `json:"k"`         // Top-K vecinos por nodo
+	MinCo    int  `json:"min_co"`    // mínimo de co-ocurrencias para aceptar un par
+	PctUsers int  `json:"pct_users"` // % de usuarios (muestreo determinístico por id)
+	PctItems int  `json:"pct_items"` // % de ítems
+	Shrink   int  `json:"shrink"`    // sim' = sim · n/(n+shrink); 0 = sin shrinkage
+	Positive bool `json:"positive"`  // descartar similitudes <= 0
+	Workers  int  `json:"workers"`   // solo RunConcurrent
+
+	UserWeight string  `json:"user_weight"` // peso de cesta: none, iuf o bm25 (ver weights.go)
+	CondAlpha  float64 `json:"cond_alpha"`  // castigo a la popularidad de métricas Directed
+}
+
+func (o Options) validate() error {
+	if _, err := ParseMode(string(o.Mode)); err != nil {
+		return err
+	}
+	if o.K <= 0 {
+		return errors.New("--k debe ser > 0")
+	}
+	if o.Shrink < 0 {
+		return errors.New("--shrink debe ser >= 0")
+	}
+	if _, err := ParseUserWeight(o.UserWeight); err != nil {
+		return err
+	}
+	if o.CondAlpha < 0 || o.CondAlpha > 1 {
+		return fmt.Errorf("--cond_alpha debe estar en [0, 1] (recibido %g)", o.CondAlpha)
+	}
+	return nil
+}
+
+// Neighbor es un vecino del Top-K.
+type Neighbor struct {
+	J int
+	S float64
+}
+
+// Result es la salida de un driver: Top-K por nodo más contadores y tiempos.
+type Result struct {
+	Metric     string
+	Options    Options
+	Concurrent bool
+	Shards     int
+	Directed   bool // métrica asimétrica: TopK[i] guarda sim(i→j)
+
+	TopK [][]Neighbor // índice = nodo; vecinos en orden descendente
+
+	Baskets  uint64 // cestas (usuarios o ítems) con al menos un valor
+	Triplets uint64 // valores usados tras el muestreo
+	Pairs    uint64 // actualizaciones de pares
+	Ranked   uint64 // pares rankeados dentro del par (métricas PairRanked)
+	Kept     uint64 // similitudes retenidas (antes del Top-K)
+	Lines    uint64 // líneas escritas en el CSV
+
+	TLoad, TNorms, TAccumulate, TTopK, TWrite time.Duration
+
+	// Labels (opcional) nombra los nodos en el reporte, p. ej. títulos de
+	// internal/catalog en mode=item.
+	Labels func(node int) string
+}
+
+// entry es un valor de una cesta: nodo y rating.
+type entry struct {
+	node int
+	r    float64
+}
+
+// ---- muestreo determinístico ----
+
+// hash32: hash determinístico simple (FNV-1a) para muestreo por id
+func hash32(x int) uint32 {
+	h := uint32(2166136261)
+	v := uint32(x)
+	for k := 0; k < 4; k++ {
+		h ^= (v >> (8 * uint(k))) & 0xff
+		h *= 16777619
+	}
+	return h
+}
+
+// KeepByPct decide si un id entra en la muestra del pct% (0..100).
+func KeepByPct(id int, pct int) bool {
+	if pct >= 100 {
+		return true
+	}
+	if pct <= 0 {
+		return false
+	}
+	return int(hash32(id)%100) < pct
+}
+
+// ---- cestas ----
+
+// baskets orienta la matriz de ratings (usuario×ítem) según el modo y
+// devuelve los porcentajes de muestreo para cestas y nodos.
+func baskets(m *csr.Matrix, opt Options) (b *csr.Matrix, pctBasket, pctNode int) {
+	if opt.Mode == ModeUser {
+		if m.Meta.Axis == csr.AxisItem {
+			return m, opt.PctItems, opt.PctUsers
+		}
+		return m.Transpose(), opt.PctItems, opt.PctUsers
+	}
+	if m.Meta.Axis == csr.AxisItem {
+		return m.Transpose(), opt.PctUsers, opt.PctItems
+	}
+	return m, opt.PctUsers, opt.PctItems
+}
+
+// basket llena buf con los valores muestreados de la fila r.
+func basket(b *csr.Matrix, r, pctNode int, buf []entry) []entry {
+	idx, val := b.Row(r)
+	for p, c := range idx {
+		if !KeepByPct(int(c), pctNode) {
+			continue
+		}
+		buf = append(buf, entry{node: int(c), r: float64(val[p])})
+	}
+	return buf
+}
+
+// nodeNorms hace el primer pase (solo si la métrica lo pide); con pesos de
+// cesta cada valoración cuenta w veces.
+func nodeNorms(b *csr.Matrix, kind Norm, weights []float64, pctBasket, pctNode int) []float64 {
+	if kind == NormNone {
+		return nil
+	}
+	norms := make([]float64, b.Cols())
+	for r := 0; r < b.Rows(); r++ {
+		if !KeepByPct(r, pctBasket) {
+			continue
+		}
+		w := weightOf(weights, r)
+		idx, val := b.Row(r)
+		for p, c := range idx {
+			if !KeepByPct(int(c), pctNode) {
+				continue
+			}
+			switch kind {
+			case NormL2:
+				x := float64(val[p])
+				norms[c] += w * x * x
+			case NormCount:
+				norms[c] += w
+			}
+		}
+	}
+	return norms
+}
+
+// ---- pares ----
+
+func pairKey(a, b int) uint64 { return uint64(a)<<32 | uint64(b) }
+
+func splitKey(k uint64) (int, int) { return int(k >> 32), int(k & 0xffffffff) }
+
+// canon ordena el par para que a < b (intercambiando también los valores).
+func canon(ea, eb entry) (uint64, float64, float64) {
+	if ea.node > eb.node {
+		ea, eb = eb, ea
+	}
+	return pairKey(ea.node, eb.node), ea.r, eb.r
+}
+
+// score finaliza un par y aplica los filtros comunes. Devuelve la similitud
+// x→y y la y→x: iguales salvo para métricas Directed.
+func score(s Similarity, t *Acc, nx, ny float64, opt Options) (xy, yx float64, okXY, okYX bool) {
+	if t.N < opt.MinCo || t.N == 0 {
+		return 0, 0, false, false
+	}
+	if d, ok := s.(Directed); ok {
+		xy, yx, ok = d.FinalizeDirected(t, nx, ny, opt.CondAlpha)
+		if !ok {
+			return 0, 0, false, false
+		}
+		xy, okXY = filter(xy, t, opt)
+		yx, okYX = filter(yx, t, opt)
+		return xy, yx, okXY, okYX
+	}
+	sim, ok := s.Finalize(t, nx, ny)
+	if !ok {
+		return 0, 0, false, false
+	}
+	sim, ok = filter(sim, t, opt)
+	return sim, sim, ok, ok
+}
+
+// filter descarta similitudes no finitas (y no positivas con --positive) y
+// aplica shrinkage.
+func filter(sim float64, t *Acc, opt Options) (float64, bool) {
+	if math.IsNaN(sim) || math.IsInf(sim, 0) {
+		return 0, false
+	}
+	if opt.Positive && sim <= 0 {
+		return 0, false
+	}
+	if opt.Shrink > 0 {
+		n := float64(t.N)
+		sim *= n / (n + float64(opt.Shrink))
+	}
+	return sim, true
+}
+
+func normOf(norms []float64, i int) float64 {
+	if norms == nil {
+		return 0
+	}
+	return norms[i]
+}
+
+// ===================== driver SECUENCIAL =====================
+
+// Run calcula el Top-K de similitudes en un solo hilo. m es la matriz de
+// ratings (filas usuarios o ítems; se reorienta según opt.Mode).
+func Run(m *csr.Matrix, s Similarity, opt Options) (*Result, error) {
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+	res := &Result{Metric: s.Name(), Options: opt, Directed: IsDirected(s)}
+
+	t0 := time.Now()
+	b, pctBasket, pctNode := baskets(m, opt)
+	weights := basketWeights(b, opt, pctBasket, pctNode)
+	norms := nodeNorms(b, s.Norm(), weights, pctBasket, pctNode)
+	t1 := time.Now()
+
+	co := make(map[uint64]*Acc, 1<<20)
+	buf := make([]entry, 0, 256)
+	for r := 0; r < b.Rows(); r++ {
+		if !KeepByPct(r, pctBasket) {
+			continue
+		}
+		buf = basket(b, r, pctNode, buf[:0])
+		if len(buf) == 0 {
+			continue
+		}
+		res.Baskets++
+		res.Triplets += uint64(len(buf))
+		w := weightOf(weights, r)
+		for a := 0; a < len(buf); a++ {
+			for c := a + 1; c < len(buf); c++ {
+				if buf[a].node == buf[c].node {
+					continue
+				}
+				key, x, y := canon(buf[a], buf[c])
+				t := co[key]
+				if t == nil {
+					t = &Acc{}
+					co[key] = t
+				}
+				s.Update(t, x, y, w)
+				res.Pairs++
+			}
+		}
+	}
+	if needsPairRanks(s) {
+		// segunda pasada: co-valorados de los pares candidatos (ver ranked.go)
+		vals := candidates(co, opt.MinCo)
+		res.Ranked = uint64(len(vals))
+		for r := 0; r < b.Rows(); r++ {
+			if !KeepByPct(r, pctBasket) {
+				continue
+			}
+			buf = basket(b, r, pctNode, buf[:0])
+			w := weightOf(weights, r)
+			for a := 0; a < len(buf); a++ {
+				for c := a + 1; c < len(buf); c++ {
+					if buf[a].node == buf[c].node {
+						continue
+					}
+					key, x, y := canon(buf[a], buf[c])
+					if v := vals[key]; v != nil {
+						v.add(x, y, w, weights != nil)
+					}
+				}
+			}
+		}
+		rerank(s, co, vals)
+	}
+	t2 := time.Now()
+
+	top := newTopK(b.Cols(), opt.K)
+	for key, t := range co {
+		i, j := splitKey(key)
+		ij, ji, okIJ, okJI := score(s, t, normOf(norms, i), normOf(norms, j), opt)
+		if okIJ {
+			top.offer(i, j, ij)
+		}
+		if okJI {
+			top.offer(j, i, ji)
+		}
+		if okIJ || okJI {
+			res.Kept++
+		}
+	}
+	res.TopK = top.sorted()
+	t3 := time.Now()
+
+	res.TNorms, res.TAccumulate, res.TTopK = t1.Sub(t0), t2.Sub(t1), t3.Sub(t2)
+	return res, nil
+}
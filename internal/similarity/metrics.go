@@ -0,0 +1,216 @@
+package similarity
+
+import "math"
+
+func init() {
+	Register(Pearson{})
+	Register(Cosine{})
+	Register(Jaccard{})
+	Register(AdjustedCosine{})
+	Register(Spearman{})
+	Register(MSD{})
+	Register(ConstrainedPearson{})
+	Register(Conditional{})
+}
+
+// Pearson: correlación lineal sobre los co-valorados.
+//
+//	sim = (Σxy - ΣxΣy/n) / ( sqrt(Σx² - (Σx)²/n) · sqrt(Σy² - (Σy)²/n) )
+//
+// Es invariante a desplazamientos por nodo, así que da lo mismo alimentarla
+// con ratings crudos o centrados.
+type Pearson struct{}
+
+func (Pearson) Name() string { return "pearson" }
+func (Pearson) Norm() Norm   { return NormNone }
+
+func (Pearson) Update(a *Acc, x, y, w float64) { a.Add(x, y, w) }
+
+func (Pearson) Finalize(a *Acc, _, _ float64) (float64, bool) {
+	n := a.W
+	num := a.SXY - (a.SX*a.SY)/n
+	denX := a.SXX - (a.SX*a.SX)/n
+	denY := a.SYY - (a.SY*a.SY)/n
+	if denX <= 0 || denY <= 0 {
+		return 0, false
+	}
+	return num / (math.Sqrt(denX) * math.Sqrt(denY)), true
+}
+
+// Cosine: coseno con normas completas de cada nodo.
+//
+//	sim = Σxy / (||x|| · ||y||),   ||x||² = Σ r² sobre todas las valoraciones del nodo
+type Cosine struct{}
+
+func (Cosine) Name() string { return "cosine" }
+func (Cosine) Norm() Norm   { return NormL2 }
+
+func (Cosine) Update(a *Acc, x, y, w float64) {
+	a.N++
+	a.SXY += w * x * y
+}
+
+func (Cosine) Finalize(a *Acc, nx, ny float64) (float64, bool) {
+	if nx <= 0 || ny <= 0 {
+		return 0, false
+	}
+	return a.SXY / (math.Sqrt(nx) * math.Sqrt(ny)), true
+}
+
+// Jaccard: solo presencia.
+//
+//	sim = inter / (|A| + |B| - inter)
+//
+// Con pesos de cesta, inter y |A|, |B| son sumas de pesos.
+type Jaccard struct{}
+
+func (Jaccard) Name() string { return "jaccard" }
+func (Jaccard) Norm() Norm   { return NormCount }
+
+func (Jaccard) Update(a *Acc, _, _, w float64) {
+	a.N++
+	a.W += w
+}
+
+func (Jaccard) Finalize(a *Acc, nx, ny float64) (float64, bool) {
+	union := nx + ny - a.W
+	if union <= 0 {
+		return 0, false
+	}
+	return a.W / union, true
+}
+
+// AdjustedCosine: coseno ajustado (Sarwar et al., 2001) sobre los
+// co-valorados, con cada rating ya centrado por la media de su cesta (en
+// mode=item, r - μ_u de matrix_user_csr).
+//
+//	sim = Σ(r_ui - μ_u)(r_uj - μ_u) / ( sqrt(Σ(r_ui - μ_u)²) · sqrt(Σ(r_uj - μ_u)²) )
+//
+// A diferencia de Pearson no resta la media de cada ítem: el centrado es
+// por usuario, que es lo que corrige la escala de cada uno.
+type AdjustedCosine struct{}
+
+func (AdjustedCosine) Name() string         { return "adjusted_cosine" }
+func (AdjustedCosine) Norm() Norm           { return NormNone }
+func (AdjustedCosine) BasketCentered() bool { return true }
+
+func (AdjustedCosine) Update(a *Acc, x, y, w float64) {
+	a.N++
+	a.SXX += w * x * x
+	a.SYY += w * y * y
+	a.SXY += w * x * y
+}
+
+func (AdjustedCosine) Finalize(a *Acc, _, _ float64) (float64, bool) {
+	if a.SXX <= 0 || a.SYY <= 0 {
+		return 0, false
+	}
+	return a.SXY / (math.Sqrt(a.SXX) * math.Sqrt(a.SYY)), true
+}
+
+// Spearman: correlación de rangos. Los co-valorados de cada par se
+// reemplazan por su rango dentro del par (empates = rango promedio, ver
+// ranked.go) y se aplica la fórmula de Pearson. Desplazar los valores de un
+// nodo no cambia sus rangos: da lo mismo alimentarla con ratings crudos o
+// centrados por nodo.
+type Spearman struct{}
+
+func (Spearman) Name() string     { return "spearman" }
+func (Spearman) Norm() Norm       { return NormNone }
+func (Spearman) PairRanked() bool { return true }
+
+func (Spearman) Update(a *Acc, x, y, w float64) { a.Add(x, y, w) }
+
+func (Spearman) Finalize(a *Acc, nx, ny float64) (float64, bool) {
+	return Pearson{}.Finalize(a, nx, ny)
+}
+
+// MSD: diferencia cuadrática media (Shardanand y Maes, 1995) sobre los
+// co-valorados, llevada a similitud en (0, 1].
+//
+//	msd = Σ(x - y)² / n      sim = 1 / (1 + msd)
+//
+// Necesita ratings crudos: con valores centrados por nodo la diferencia
+// mezclaría las medias de cada uno.
+type MSD struct{}
+
+func (MSD) Name() string     { return "msd" }
+func (MSD) Norm() Norm       { return NormNone }
+func (MSD) RawRatings() bool { return true }
+
+func (MSD) Update(a *Acc, x, y, w float64) {
+	a.N++
+	a.W += w
+	a.SXY += w * (x - y) * (x - y)
+}
+
+func (MSD) Finalize(a *Acc, _, _ float64) (float64, bool) {
+	if a.W <= 0 {
+		return 0, false
+	}
+	return 1 / (1 + a.SXY/a.W), true
+}
+
+// ConstrainedPearson: Pearson centrado en el punto medio de la escala en
+// lugar de la media de cada nodo (Shardanand y Maes, 1995), así dos usuarios
+// que coinciden en "le gustó / no le gustó" correlacionan aunque sus medias
+// difieran.
+//
+//	sim = Σ(x - 3)(y - 3) / ( sqrt(Σ(x - 3)²) · sqrt(Σ(y - 3)²) )
+type ConstrainedPearson struct{}
+
+// ScaleMidpoint es el centro de la escala de 0.5 a 5 estrellas que usa
+// ConstrainedPearson.
+const ScaleMidpoint = 3.0
+
+func (ConstrainedPearson) Name() string     { return "constrained_pearson" }
+func (ConstrainedPearson) Norm() Norm       { return NormNone }
+func (ConstrainedPearson) RawRatings() bool { return true }
+
+func (ConstrainedPearson) Update(a *Acc, x, y, w float64) {
+	x, y = x-ScaleMidpoint, y-ScaleMidpoint
+	a.N++
+	a.SXX += w * x * x
+	a.SYY += w * y * y
+	a.SXY += w * x * y
+}
+
+func (ConstrainedPearson) Finalize(a *Acc, _, _ float64) (float64, bool) {
+	if a.SXX <= 0 || a.SYY <= 0 {
+		return 0, false
+	}
+	return a.SXY / (math.Sqrt(a.SXX) * math.Sqrt(a.SYY)), true
+}
+
+// Conditional: probabilidad condicional de Deshpande y Karypis (2004), la
+// única métrica asimétrica. Para el par (x, y) con frecuencias f(x), f(y)
+// (cestas que contienen a cada nodo) y f(xy) co-ocurrencias:
+//
+//	sim(x→y) = f(xy) / ( f(x) · f(y)^α )   ∝ P(y|x) / P(y)^α
+//	sim(y→x) = f(xy) / ( f(y) · f(x)^α )
+//
+// α ∈ [0, 1] (--cond_alpha) castiga a los nodos populares: con α = 0 es P(y|x).
+// Los drivers llaman a FinalizeDirected y guardan ambas direcciones; en la
+// salida, la fila (i, j, s) es s(i→j).
+type Conditional struct{}
+
+func (Conditional) Name() string { return "conditional" }
+func (Conditional) Norm() Norm   { return NormCount }
+
+func (Conditional) Update(a *Acc, _, _, w float64) {
+	a.N++
+	a.W += w
+}
+
+// Finalize devuelve sim(x→y) con α = 0.
+func (c Conditional) Finalize(a *Acc, nx, ny float64) (float64, bool) {
+	xy, _, ok := c.FinalizeDirected(a, nx, ny, 0)
+	return xy, ok
+}
+
+func (Conditional) FinalizeDirected(a *Acc, nx, ny, alpha float64) (xy, yx float64, ok bool) {
+	if nx <= 0 || ny <= 0 {
+		return 0, 0, false
+	}
+	return a.W / (nx * math.Pow(ny, alpha)), a.W / (ny * math.Pow(nx, alpha)), true
+}
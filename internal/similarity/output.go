@@ -0,0 +1,170 @@
+package similarity
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pc3/internal/report"
+)
+
+// Header devuelve la cabecera del CSV Top-K según el modo.
+func Header(mode Mode) []string {
+	if mode == ModeUser {
+		return []string{"uIdx", "vIdx", "sim"}
+	}
+	return []string{"iIdx", "jIdx", "sim"}
+}
+
+// WriteCSV escribe el Top-K como (nodo,vecino,sim), nodos en orden creciente.
+func (r *Result) WriteCSV(path string) error {
+	t0 := time.Now()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriterSize(f, 1<<20)
+
+	r.Lines = 0
+	w.WriteString(strings.Join(Header(r.Options.Mode), ",") + "\n")
+	line := make([]byte, 0, 64)
+	for i, list := range r.TopK {
+		for _, p := range list {
+			line = strconv.AppendInt(line[:0], int64(i), 10)
+			line = append(line, ',')
+			line = strconv.AppendInt(line, int64(p.J), 10)
+			line = append(line, ',')
+			line = strconv.AppendFloat(line, p.S, 'f', 6, 64)
+			line = append(line, '\n')
+			if _, err := w.Write(line); err != nil {
+				return err
+			}
+			r.Lines++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	r.TWrite = time.Since(t0)
+	return nil
+}
+
+// Report arma el reporte de texto de la corrida (mismo formato para todas
+// las métricas y ambos drivers).
+func (r *Result) Report(out string) string {
+	o := r.Options
+	kind := "secuencial"
+	if r.Concurrent {
+		kind = "concurrente, shardeado"
+	}
+	if o.Shrink > 0 {
+		kind += " + shrinkage"
+	}
+	basketLabel, pairLabel := "Usuarios usados (cestas)", "Pares (i,j) acumulados  "
+	if o.Mode == ModeUser {
+		basketLabel, pairLabel = "Ítems usados (cestas)   ", "Pares (u,v) acumulados  "
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "== %s %s-BASED (%s) ==\n", strings.ToUpper(r.Metric), strings.ToUpper(string(o.Mode)), kind)
+	fmt.Fprintf(&b, "pct_users / pct_items   : %d%% / %d%%\n", o.PctUsers, o.PctItems)
+	if r.Concurrent {
+		fmt.Fprintf(&b, "Workers (goroutines)    : %d\n", o.Workers)
+		fmt.Fprintf(&b, "Shards globales         : %d\n", r.Shards)
+	}
+	fmt.Fprintf(&b, "Shrink (λ)              : %d\n", o.Shrink)
+	fmt.Fprintf(&b, "Solo positivas          : %v\n", o.Positive)
+	fmt.Fprintf(&b, "Peso de cesta           : %s\n\n", describeWeight(o.UserWeight))
+
+	fmt.Fprintf(&b, "%s: %d\n", basketLabel, r.Baskets)
+	fmt.Fprintf(&b, "Tripletas usadas        : %d\n", r.Triplets)
+	fmt.Fprintf(&b, "%s: %d\n", pairLabel, r.Pairs)
+	if r.Ranked > 0 {
+		fmt.Fprintf(&b, "Pares con rangos        : %d\n", r.Ranked)
+	}
+	fmt.Fprintf(&b, "Similitudes retenidas   : %d\n", r.Kept)
+	fmt.Fprintf(&b, "Líneas escritas (CSV)   : %d\n", r.Lines)
+	if r.Directed {
+		fmt.Fprintf(&b, "Parámetros              : k=%d  min_co=%d  cond_alpha=%g (asimétrica: fila i,j = sim(i→j))\n\n", o.K, o.MinCo, o.CondAlpha)
+	} else {
+		fmt.Fprintf(&b, "Parámetros              : k=%d  min_co=%d\n\n", o.K, o.MinCo)
+	}
+
+	total := r.TLoad + r.TNorms + r.TAccumulate + r.TTopK + r.TWrite
+	fmt.Fprintf(&b, "Tiempos:\n")
+	fmt.Fprintf(&b, "  Cargar ratings        : %s\n", r.TLoad)
+	fmt.Fprintf(&b, "  Cestas + normas       : %s\n", r.TNorms)
+	fmt.Fprintf(&b, "  Acumular pares        : %s\n", r.TAccumulate)
+	fmt.Fprintf(&b, "  Top-K por nodo        : %s\n", r.TTopK)
+	fmt.Fprintf(&b, "  Escribir CSV          : %s\n", r.TWrite)
+	fmt.Fprintf(&b, "  TOTAL                 : %s\n\n", total)
+
+	if r.Labels != nil {
+		r.writeExamples(&b, exampleNodes, exampleNeighbors)
+	}
+
+	fmt.Fprintf(&b, "Salida CSV:\n  %s\n", out)
+	return b.String()
+}
+
+// JSON arma la versión estructurada de Report.
+func (r *Result) JSON(out string) *report.Report {
+	rep := report.New("sim", r.Options)
+	rep.Set("metric", r.Metric)
+	rep.Set("concurrent", r.Concurrent)
+	rep.Set("directed", r.Directed)
+	if r.Concurrent {
+		rep.Count("shards", int64(r.Shards))
+	}
+	rep.Count("baskets", int64(r.Baskets))
+	rep.Count("triplets", int64(r.Triplets))
+	rep.Count("pairs", int64(r.Pairs))
+	if r.Ranked > 0 {
+		rep.Count("ranked_pairs", int64(r.Ranked))
+	}
+	rep.Count("kept", int64(r.Kept))
+	rep.Count("lines", int64(r.Lines))
+	rep.Time("load", r.TLoad)
+	rep.Time("norms", r.TNorms)
+	rep.Time("accumulate", r.TAccumulate)
+	rep.Time("topk", r.TTopK)
+	rep.Time("write", r.TWrite)
+	rep.Time("total", r.TLoad+r.TNorms+r.TAccumulate+r.TTopK+r.TWrite)
+	rep.Output("topk", out)
+	return rep
+}
+
+// Cantidad de nodos y vecinos que muestra la sección de ejemplos.
+const (
+	exampleNodes     = 5
+	exampleNeighbors = 5
+)
+
+// writeExamples lista los vecinos de los primeros nodos con vecinos, con
+// los nombres de r.Labels.
+func (r *Result) writeExamples(b *strings.Builder, nodes, neighbors int) {
+	fmt.Fprintf(b, "Vecinos de ejemplo:\n")
+	shown := 0
+	for i, list := range r.TopK {
+		if len(list) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "  %s\n", r.Labels(i))
+		for _, p := range list[:min(neighbors, len(list))] {
+			fmt.Fprintf(b, "    %.4f  %s\n", p.S, r.Labels(int(p.J)))
+		}
+		shown++
+		if shown == nodes {
+			break
+		}
+	}
+	fmt.Fprintf(b, "\n")
+}
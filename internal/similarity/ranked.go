@@ -0,0 +1,96 @@
+package similarity
+
+/*
+RANGOS POR PAR (Spearman)
+
+Spearman es Pearson sobre los rangos de los co-valorados del par, y esos
+rangos dependen de qué valores comparte cada par: no salen de los momentos
+de Acc. Las métricas PairRanked se calculan en dos pasadas:
+
+ 1. Pasada normal: Update acumula como siempre y Acc.N dice qué pares
+    llegan a min_co.
+ 2. candidates reserva un pairVals por cada par con N ≥ max(min_co, 2).
+ 3. Segunda pasada por las mismas cestas: cada par candidato guarda sus
+    (x, y) y el peso de la cesta.
+ 4. rerank rankea x e y dentro del par (1 = el menor, empates = rango
+    promedio) y reemplaza el Acc por los momentos de esos rangos, que luego
+    Finalize lleva a Pearson. Las co-ocurrencias son las mismas, así que N,
+    min_co y shrinkage no cambian.
+
+Memoria: además de los acumuladores, 8 bytes por co-ocurrencia de cada par
+candidato (12 con --user_weight). En el corpus completo conviene subir
+--min_co o muestrear con --pct_users / --pct_items.
+*/
+
+import "sort"
+
+// pairVals son los co-valorados de un par candidato.
+type pairVals struct {
+	x, y []float32
+	w    []float32 // pesos de cesta; nil sin --user_weight
+}
+
+// add guarda una co-ocurrencia del par; weighted indica si hay pesos de cesta.
+func (v *pairVals) add(x, y, w float64, weighted bool) {
+	v.x = append(v.x, float32(x))
+	v.y = append(v.y, float32(y))
+	if weighted {
+		v.w = append(v.w, float32(w))
+	}
+}
+
+// candidates reserva un pairVals para cada par de co con suficientes
+// co-ocurrencias para pasar min_co y tener rangos (al menos dos).
+func candidates(co map[uint64]*Acc, minCo int) map[uint64]*pairVals {
+	vals := make(map[uint64]*pairVals)
+	for key, t := range co {
+		if t.N >= max(minCo, 2) {
+			vals[key] = &pairVals{x: make([]float32, 0, t.N), y: make([]float32, 0, t.N)}
+		}
+	}
+	return vals
+}
+
+// rerank reemplaza el acumulador de cada par de vals por los momentos de sus
+// rangos dentro del par y libera los valores.
+func rerank(s Similarity, co map[uint64]*Acc, vals map[uint64]*pairVals) {
+	var order []int
+	var rx, ry []float64
+	for key, v := range vals {
+		order, rx = pairRanks(v.x, order, rx)
+		order, ry = pairRanks(v.y, order, ry)
+		t := co[key]
+		*t = Acc{}
+		for k := range rx {
+			w := 1.0
+			if v.w != nil {
+				w = float64(v.w[k])
+			}
+			s.Update(t, rx[k], ry[k], w)
+		}
+		delete(vals, key)
+	}
+}
+
+// pairRanks deja en out el rango de cada v[k] dentro de v (1 = el menor,
+// empates con el rango promedio). order y out son buffers reutilizables.
+func pairRanks(v []float32, order []int, out []float64) ([]int, []float64) {
+	order, out = order[:0], out[:0]
+	for k := range v {
+		order = append(order, k)
+		out = append(out, 0)
+	}
+	sort.Slice(order, func(a, b int) bool { return v[order[a]] < v[order[b]] })
+	for lo := 0; lo < len(order); {
+		hi := lo + 1
+		for hi < len(order) && v[order[hi]] == v[order[lo]] {
+			hi++
+		}
+		r := float64(lo+hi+1) / 2 // rango promedio de lo+1 … hi
+		for _, k := range order[lo:hi] {
+			out[k] = r
+		}
+		lo = hi
+	}
+	return order, out
+}
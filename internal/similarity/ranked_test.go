@@ -0,0 +1,70 @@
+package similarity
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestPairRanks(t *testing.T) {
+	_, got := pairRanks([]float32{3, 1, 3, 2, -1}, nil, nil)
+	want := []float64{4.5, 2, 4.5, 3, 1}
+	if !slices.Equal(got, want) {
+		t.Fatalf("pairRanks = %v, se espera %v", got, want)
+	}
+}
+
+// spearmanRef calcula Spearman a fuerza bruta sobre los co-valorados de un
+// par: los rankea dentro del par y aplica Pearson.
+func spearmanRef(xs, ys []float32) (float64, bool) {
+	_, rx := pairRanks(xs, nil, nil)
+	_, ry := pairRanks(ys, nil, nil)
+	var a Acc
+	for k := range rx {
+		a.Add(rx[k], ry[k], 1)
+	}
+	return Pearson{}.Finalize(&a, 0, 0)
+}
+
+func TestSpearmanRanksWithinPair(t *testing.T) {
+	m := fixture(t)
+	// columnas por ítem: usuario -> rating
+	cols := make([]map[int]float32, m.Cols())
+	for u := 0; u < m.Rows(); u++ {
+		idx, val := m.Row(u)
+		for p, i := range idx {
+			if cols[i] == nil {
+				cols[i] = map[int]float32{}
+			}
+			cols[i][u] = val[p]
+		}
+	}
+
+	opt := Options{Mode: ModeItem, K: 64, MinCo: 3, PctUsers: 100, PctItems: 100, Workers: 4}
+	for _, run := range []func() (*Result, error){
+		func() (*Result, error) { return Run(m, Spearman{}, opt) },
+		func() (*Result, error) { return RunConcurrent(m, Spearman{}, opt) },
+	} {
+		res, err := run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Ranked == 0 {
+			t.Fatal("ningún par rankeado")
+		}
+		for i, nbrs := range res.TopK {
+			for _, n := range nbrs {
+				var xs, ys []float32
+				for u, x := range cols[i] {
+					if y, ok := cols[n.J][u]; ok {
+						xs, ys = append(xs, x), append(ys, y)
+					}
+				}
+				want, ok := spearmanRef(xs, ys)
+				if !ok || math.Abs(want-n.S) > 1e-9 {
+					t.Errorf("concurrente=%v par (%d,%d): sim=%v, se espera %v (ok=%v)", res.Concurrent, i, n.J, n.S, want, ok)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package similarity
+
+/*
+SIMILITUD PLUGGABLE (Pearson, Coseno, Jaccard, coseno ajustado, Spearman,
+MSD, Pearson restringido, probabilidad condicional, …) — USER o ITEM
+
+Todas las métricas comparten el mismo esquema:
+
+ 1. Cestas: cada fila de la matriz de entrada es una "cesta" y sus columnas
+    son los nodos a comparar.
+      - mode=item: cesta = usuario, nodos = ítems que calificó.
+      - mode=user: cesta = ítem,    nodos = usuarios que lo calificaron.
+ 2. Normas por nodo (opcional): un primer pase calcula lo que la métrica pida
+    (Σr² para coseno, |U(i)| para Jaccard, …).
+ 3. Acumulación: por cada par (a,b) dentro de una cesta se llama a Update
+    sobre el acumulador del par. Los pares se canonizan a a < b; x es el
+    valor del nodo a e y el del nodo b. Con --user_weight cada cesta pesa
+    w (ver weights.go) en los momentos y en las normas; sin él, w = 1.
+ 4. Finalización: Finalize convierte el acumulador (más las normas) en la
+    similitud. Luego el driver aplica min_co, filtro de no positivos y
+    shrinkage, y se queda con el Top-K por nodo.
+
+Las métricas que necesitan otra entrada lo declaran con interfaces
+opcionales: BasketCentered (valores centrados por cesta), RawRatings
+(ratings crudos) y PairRanked (rangos dentro de cada par, ver ranked.go).
+Directed marca las asimétricas: el par canonizado se finaliza en ambas
+direcciones y cada nodo recibe su propia similitud hacia el otro.
+
+Una métrica nueva solo implementa la interfaz Similarity; los drivers
+secuencial (Run) y concurrente (RunConcurrent) son los mismos para todas.
+*/
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Norm indica qué estadístico por nodo necesita una métrica.
+type Norm int
+
+const (
+	NormNone  Norm = iota // no necesita normas
+	NormL2                // Σ r² sobre todas las valoraciones (muestreadas) del nodo
+	NormCount             // número de valoraciones del nodo (grado)
+)
+
+// Acc acumula los momentos de un par canonizado (x = nodo menor, y = nodo mayor).
+// Cada métrica usa los campos que necesite.
+type Acc struct {
+	N                     int     // co-ocurrencias (sin ponderar: min_co y shrinkage)
+	W                     float64 // Σ pesos de cesta (= N con --user_weight=none)
+	SX, SY, SXX, SYY, SXY float64
+}
+
+// Add acumula todos los momentos de una co-ocurrencia (x, y) con el peso w
+// de su cesta.
+func (a *Acc) Add(x, y, w float64) {
+	a.N++
+	a.W += w
+	a.SX += w * x
+	a.SY += w * y
+	a.SXX += w * x * x
+	a.SYY += w * y * y
+	a.SXY += w * x * y
+}
+
+// Similarity es una métrica de similitud entre nodos.
+type Similarity interface {
+	// Name es el nombre corto usado en flags y archivos (pearson, cosine, …).
+	Name() string
+	// Norm indica qué normas por nodo hay que precalcular.
+	Norm() Norm
+	// Update acumula una co-ocurrencia del par con valores x (nodo menor) e
+	// y, ponderada por el peso w de la cesta (1 sin --user_weight).
+	Update(a *Acc, x, y, w float64)
+	// Finalize calcula la similitud a partir del acumulador y las normas de
+	// ambos nodos (0 si Norm() == NormNone). ok=false descarta el par.
+	Finalize(a *Acc, nx, ny float64) (sim float64, ok bool)
+}
+
+// BasketCentered la implementan las métricas que esperan cada valor ya
+// centrado por la media de su cesta (AdjustedCosine): en mode=item el CSR
+// por usuario, en mode=user el CSR por ítem.
+type BasketCentered interface {
+	BasketCentered() bool
+}
+
+// NeedsCentered indica si s espera valores centrados por cesta.
+func NeedsCentered(s Similarity) bool {
+	c, ok := s.(BasketCentered)
+	return ok && c.BasketCentered()
+}
+
+// RawRatings la implementan las métricas que necesitan los ratings tal
+// cual, sin centrar (MSD, ConstrainedPearson).
+type RawRatings interface {
+	RawRatings() bool
+}
+
+// NeedsRaw indica si s necesita ratings sin centrar.
+func NeedsRaw(s Similarity) bool {
+	r, ok := s.(RawRatings)
+	return ok && r.RawRatings()
+}
+
+// PairRanked la implementan las métricas que comparan rangos (Spearman):
+// tras acumular, los drivers rankean los co-valorados de cada par que pasó
+// min_co dentro del propio par y vuelven a acumular esos rangos con Update
+// antes de Finalize (ver ranked.go).
+type PairRanked interface {
+	PairRanked() bool
+}
+
+func needsPairRanks(s Similarity) bool {
+	r, ok := s.(PairRanked)
+	return ok && r.PairRanked()
+}
+
+// Directed la implementan las métricas asimétricas (Conditional): en lugar
+// de Finalize, los drivers piden ambas direcciones del par y ofrecen cada
+// una al Top-K de su nodo de origen. alpha es Options.CondAlpha.
+type Directed interface {
+	FinalizeDirected(a *Acc, nx, ny, alpha float64) (xy, yx float64, ok bool)
+}
+
+// IsDirected indica si s da una similitud distinta en cada dirección.
+func IsDirected(s Similarity) bool {
+	_, ok := s.(Directed)
+	return ok
+}
+
+var registry = map[string]Similarity{}
+
+// Register agrega una métrica al registro por nombre.
+func Register(s Similarity) {
+	registry[s.Name()] = s
+}
+
+// ByName devuelve la métrica registrada con ese nombre.
+func ByName(name string) (Similarity, error) {
+	s, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("métrica desconocida %q (disponibles: %s)", name, strings.Join(Names(), ", "))
+	}
+	return s, nil
+}
+
+// Names lista las métricas registradas, en orden alfabético.
+func Names() []string {
+	out := make([]string, 0, len(registry))
+	for n := range registry {
+		out = append(out, n)
+	}
+	sort.Strings(out)
+	return out
+}
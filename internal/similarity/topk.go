@@ -0,0 +1,72 @@
+package similarity
+
+import "sort"
+
+// topK mantiene, por nodo, un min-heap acotado a k vecinos: la raíz es el
+// peor vecino retenido, así cada oferta cuesta O(log k) en vez de reordenar.
+type topK struct {
+	k     int
+	heaps [][]Neighbor
+}
+
+func newTopK(nodes, k int) *topK {
+	return &topK{k: k, heaps: make([][]Neighbor, nodes)}
+}
+
+// worse define el orden del heap: menor similitud (y, a igualdad, mayor id)
+// es "peor", para que el resultado sea determinístico.
+func worse(a, b Neighbor) bool {
+	if a.S != b.S {
+		return a.S < b.S
+	}
+	return a.J > b.J
+}
+
+func (t *topK) offer(i, j int, s float64) {
+	h := t.heaps[i]
+	n := Neighbor{J: j, S: s}
+	if len(h) < t.k {
+		h = append(h, n)
+		// sift-up
+		c := len(h) - 1
+		for c > 0 {
+			p := (c - 1) / 2
+			if !worse(h[c], h[p]) {
+				break
+			}
+			h[c], h[p] = h[p], h[c]
+			c = p
+		}
+		t.heaps[i] = h
+		return
+	}
+	if !worse(h[0], n) {
+		return
+	}
+	h[0] = n
+	// sift-down
+	p := 0
+	for {
+		l, r := 2*p+1, 2*p+2
+		m := p
+		if l < len(h) && worse(h[l], h[m]) {
+			m = l
+		}
+		if r < len(h) && worse(h[r], h[m]) {
+			m = r
+		}
+		if m == p {
+			break
+		}
+		h[p], h[m] = h[m], h[p]
+		p = m
+	}
+}
+
+// sorted devuelve los vecinos de cada nodo en orden descendente.
+func (t *topK) sorted() [][]Neighbor {
+	for _, h := range t.heaps {
+		sort.Slice(h, func(a, b int) bool { return worse(h[b], h[a]) })
+	}
+	return t.heaps
+}
@@ -0,0 +1,110 @@
+package similarity
+
+/*
+PESOS DE CESTA (--user_weight)
+
+Un usuario con miles de ratings aporta n·(n-1)/2 co-ocurrencias en mode=item
+y domina los acumuladores de casi todos los pares. Con --user_weight cada
+cesta pesa w en Update y en las normas por nodo (ver similarity.go):
+
+  none  w = 1 (por defecto; resultados idénticos a no ponderar)
+  iuf   w = log(N / n_b)   N = nodos, n_b = nodos muestreados de la cesta
+        (en mode=item: log(#ítems / #ratings del usuario)); w ≥ 0
+  bm25  w = (k1+1) / (1 + k1·(1 - b + b·n_b/avg_n))   k1 = 1.2, b = 0.75
+        (normalización por largo de BM25 con tf = 1: la cesta de largo
+        promedio pesa 1, las más largas menos, sin llegar a 0)
+
+En mode=user las cestas son ítems y el peso castiga a los ítems populares.
+min_co y shrinkage siguen contando co-ocurrencias sin ponderar (Acc.N).
+*/
+
+import (
+	"fmt"
+	"math"
+
+	"pc3/internal/csr"
+)
+
+// Valores de --user_weight.
+const (
+	WeightNone = "none"
+	WeightIUF  = "iuf"
+	WeightBM25 = "bm25"
+)
+
+// Parámetros de bm25.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// ParseUserWeight valida el valor de --user_weight ("" equivale a none).
+func ParseUserWeight(s string) (string, error) {
+	switch s {
+	case "", WeightNone:
+		return WeightNone, nil
+	case WeightIUF, WeightBM25:
+		return s, nil
+	}
+	return "", fmt.Errorf("--user_weight debe ser none, iuf o bm25 (recibido %q)", s)
+}
+
+// basketWeights calcula el peso de cada cesta (fila de b) según opt.UserWeight.
+// nil con none: los drivers usan w = 1.
+func basketWeights(b *csr.Matrix, opt Options, pctBasket, pctNode int) []float64 {
+	if opt.UserWeight == "" || opt.UserWeight == WeightNone {
+		return nil
+	}
+	n := make([]int, b.Rows())
+	var total, used int
+	for r := 0; r < b.Rows(); r++ {
+		if !KeepByPct(r, pctBasket) {
+			continue
+		}
+		idx, _ := b.Row(r)
+		for _, c := range idx {
+			if KeepByPct(int(c), pctNode) {
+				n[r]++
+			}
+		}
+		if n[r] > 0 {
+			total += n[r]
+			used++
+		}
+	}
+
+	w := make([]float64, b.Rows())
+	nodes := float64(b.Cols())
+	avg := float64(total) / math.Max(float64(used), 1)
+	for r, nb := range n {
+		if nb == 0 {
+			continue
+		}
+		switch opt.UserWeight {
+		case WeightIUF:
+			w[r] = math.Max(0, math.Log(nodes/float64(nb)))
+		case WeightBM25:
+			w[r] = (bm25K1 + 1) / (1 + bm25K1*(1-bm25B+bm25B*float64(nb)/avg))
+		}
+	}
+	return w
+}
+
+// weightOf devuelve el peso de la cesta r (1 sin pesos).
+func weightOf(w []float64, r int) float64 {
+	if w == nil {
+		return 1
+	}
+	return w[r]
+}
+
+// describeWeight es la línea del reporte para --user_weight.
+func describeWeight(kind string) string {
+	switch kind {
+	case WeightIUF:
+		return "iuf (log(N/n_cesta))"
+	case WeightBM25:
+		return fmt.Sprintf("bm25 (k1=%g, b=%g)", bm25K1, bm25B)
+	}
+	return WeightNone
+}